@@ -1,52 +1,31 @@
 package gojson
 
-func action(stack []*stackElement) (*jsonElement, int) {
-	stackSize := len(stack)
-
-	var je *jsonElement
-	var offset int
-
-	for _, rule := range grammar {
-		for _, production := range rule.rhs {
-			size := len(production)
-			if size > stackSize {
-				continue
-			}
-			actual := topNOfStack(stack, size)
-			matches := compare(production, actual)
-			if matches && size > offset {
-				je = &jsonElement{
-					value:           rule.toJson(stack[len(stack)-size:]...),
-					jsonElementType: rule.lhs,
-				}
-				offset = size
-			}
+// reduceValues replaces the elements of the production on top of the stack
+// with the one the rule builds from them. If errs is not nil, the error of the rule
+// is collected there and the element is marked as invalid instead of failing
+func reduceValues(stack []*stackElement, action lrAction, opts *ParseOptions, errs *[]*Error) ([]*stackElement, *Error) {
+	rule := &grammar[action.rule]
+	size := len(rule.rhs[action.production])
+	values := stack[len(stack)-size:]
+
+	value, err := rule.toJson(opts, values...)
+	if err != nil {
+		if errs == nil {
+			return nil, err
 		}
+		*errs = append(*errs, err)
+		value = JsonValue{Value: err, ValueType: INVALID}
 	}
 
-	return je, offset
-}
-
-func topNOfStack(stack []*stackElement, count int) []elementType {
-	slice := stack[len(stack)-count:]
-	var elements []elementType
-
-	for _, el := range slice {
-		var value = el.value.tokenType
-		if el.rule != nil {
-			value = el.rule.jsonElementType
-		}
-		elements = append(elements, value)
+	element := &stackElement{
+		rule: &jsonElement{
+			value:           value,
+			jsonElementType: rule.lhs,
+			start:           values[0].Start(),
+			end:             values[size-1].End(),
+		},
 	}
 
-	return elements
-}
-
-func compare(expansion, actual []elementType) bool {
-	for i := 0; i < len(expansion); i++ {
-		if expansion[i] != actual[i] {
-			return false
-		}
-	}
-	return true
+	stack = stack[:len(stack)-size]
+	return append(stack, element), nil
 }
@@ -0,0 +1,410 @@
+package gojson
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type ConflictKind = string
+
+const (
+	ShiftReduceConflict  ConflictKind = "shift/reduce"
+	ReduceReduceConflict ConflictKind = "reduce/reduce"
+)
+
+// GrammarConflict is a place where the parsing tables cannot decide
+// what to do by looking at the next terminal alone
+type GrammarConflict struct {
+	Kind ConflictKind
+	// State is the number of the automaton state the conflict is in
+	State int
+	// Lookahead is the terminal that leads to more than one action
+	Lookahead string
+	// Items are the competing productions, with a dot marking how much of them has been seen
+	Items []string
+}
+
+func (c GrammarConflict) String() string {
+	return fmt.Sprintf("%s conflict in state %d on %s:\n\t%s", c.Kind, c.State, c.Lookahead, strings.Join(c.Items, "\n\t"))
+}
+
+// GrammarAnalysis describes the grammar the parser is generated from
+type GrammarAnalysis struct {
+	// Conflicts lists the ambiguities of the grammar. It is empty for the json grammar:
+	// any conflict would mean that some input is parsed in an arbitrary way
+	Conflicts []GrammarConflict
+	// RecoveryConflicts lists the ambiguities of the tables the parser runs on, which also hold
+	// the placeholders of malformed input ParseAll recovers with. It is empty as well:
+	// any conflict would be resolved silently, in favour of shifting
+	RecoveryConflicts []GrammarConflict
+	// First maps every nonterminal to the terminals it can start with
+	First map[string][]string
+	// Follow maps every nonterminal to the terminals that can come right after it
+	Follow map[string][]string
+	// Unreachable lists the nonterminals that cannot be derived from <value>
+	Unreachable []string
+}
+
+// AnalyzeGrammar inspects the grammar the parser is generated from
+func AnalyzeGrammar() GrammarAnalysis {
+	analysis := jsonAutomaton().analyze()
+	analysis.RecoveryConflicts = automaton.conflicts
+	return analysis
+}
+
+// jsonAutomaton builds the automaton of the json grammar alone. The productions of
+// the placeholders of malformed input only serve ParseAll, they are not part of json
+func jsonAutomaton() *lrAutomaton {
+	return buildAutomaton(withoutRecovery(grammar), value)
+}
+
+// withoutRecovery leaves out the productions using the placeholders of malformed input
+func withoutRecovery(rules []grammarRule) []grammarRule {
+	var filtered []grammarRule
+	for _, rule := range rules {
+		var productions [][]elementType
+		for _, production := range rule.rhs {
+			recovery := false
+			for _, symbol := range production {
+				recovery = recovery || symbol == ltInvalid || symbol == ltInvalidMember
+			}
+			if !recovery {
+				productions = append(productions, production)
+			}
+		}
+		if len(productions) > 0 {
+			filtered = append(filtered, grammarRule{rule.lhs, productions, rule.toJson})
+		}
+	}
+	return filtered
+}
+
+func (a *lrAutomaton) analyze() GrammarAnalysis {
+	return GrammarAnalysis{
+		Conflicts:   a.conflicts,
+		First:       sortedSets(a.first),
+		Follow:      sortedSets(a.followSets()),
+		Unreachable: a.unreachable(),
+	}
+}
+
+// conflict records the items of the state that compete for the lookahead
+func (a *lrAutomaton) conflict(kind ConflictKind, state int, lookahead elementType) {
+	var items []string
+	seen := map[string]bool{}
+	for _, item := range a.states[state] {
+		rhs := a.rhsOf(item.rule, item.production)
+		reduces := item.dot == len(rhs) && item.lookahead == lookahead
+		shifts := item.dot < len(rhs) && rhs[item.dot] == lookahead
+		if !reduces && !shifts {
+			continue
+		}
+		if description := a.describeItem(item); !seen[description] {
+			seen[description] = true
+			items = append(items, description)
+		}
+	}
+
+	a.conflicts = append(a.conflicts, GrammarConflict{
+		Kind:      kind,
+		State:     state,
+		Lookahead: lookahead,
+		Items:     items,
+	})
+}
+
+func (a *lrAutomaton) describeItem(item lrItem) string {
+	rhs := a.rhsOf(item.rule, item.production)
+	symbols := make([]string, 0, len(rhs)+1)
+	for i, symbol := range rhs {
+		if i == item.dot {
+			symbols = append(symbols, "•")
+		}
+		symbols = append(symbols, a.bnfSymbol(symbol))
+	}
+	if item.dot == len(rhs) {
+		symbols = append(symbols, "•")
+	}
+	return fmt.Sprintf("%s ::= %s", a.lhsOf(item.rule), strings.Join(symbols, " "))
+}
+
+// followSets computes the terminals that can come right after each nonterminal.
+// None of the productions are empty, so the symbol right after is all that matters
+func (a *lrAutomaton) followSets() map[elementType]map[elementType]bool {
+	follow := map[elementType]map[elementType]bool{}
+	for _, rule := range a.rules {
+		follow[rule.lhs] = map[elementType]bool{}
+	}
+	follow[a.start][ltEnd] = true
+
+	for changed := true; changed; {
+		changed = false
+		for _, rule := range a.rules {
+			for _, production := range rule.rhs {
+				for i, symbol := range production {
+					if !a.isNonterminal(symbol) {
+						continue
+					}
+
+					candidates := follow[rule.lhs]
+					if i+1 < len(production) {
+						next := production[i+1]
+						candidates = map[elementType]bool{next: true}
+						if a.isNonterminal(next) {
+							candidates = a.first[next]
+						}
+					}
+
+					for terminal := range candidates {
+						if !follow[symbol][terminal] {
+							follow[symbol][terminal] = true
+							changed = true
+						}
+					}
+				}
+			}
+		}
+	}
+	return follow
+}
+
+// unreachable finds the nonterminals that no derivation of the start symbol uses
+func (a *lrAutomaton) unreachable() []string {
+	reached := map[elementType]bool{a.start: true}
+	queue := []elementType{a.start}
+	for len(queue) > 0 {
+		symbol := queue[0]
+		queue = queue[1:]
+		for _, rule := range a.rules {
+			if rule.lhs != symbol {
+				continue
+			}
+			for _, production := range rule.rhs {
+				for _, s := range production {
+					if a.isNonterminal(s) && !reached[s] {
+						reached[s] = true
+						queue = append(queue, s)
+					}
+				}
+			}
+		}
+	}
+
+	var unreachable []string
+	for _, lhs := range a.nonterminals() {
+		if !reached[lhs] {
+			unreachable = append(unreachable, lhs)
+		}
+	}
+	return unreachable
+}
+
+// nonterminals lists the left-hand sides of the rules in the order they are defined
+func (a *lrAutomaton) nonterminals() []elementType {
+	var nonterminals []elementType
+	seen := map[elementType]bool{}
+	for _, rule := range a.rules {
+		if !seen[rule.lhs] {
+			seen[rule.lhs] = true
+			nonterminals = append(nonterminals, rule.lhs)
+		}
+	}
+	return nonterminals
+}
+
+func sortedSets(sets map[elementType]map[elementType]bool) map[string][]string {
+	sorted := map[string][]string{}
+	for symbol, set := range sets {
+		terminals := make([]string, 0, len(set))
+		for terminal := range set {
+			terminals = append(terminals, terminal)
+		}
+		sort.Strings(terminals)
+		sorted[symbol] = terminals
+	}
+	return sorted
+}
+
+func (g GrammarAnalysis) String() string {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("conflicts: %d\n", len(g.Conflicts)))
+	for _, conflict := range g.Conflicts {
+		sb.WriteString(conflict.String())
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString(fmt.Sprintf("conflicts with error recovery: %d\n", len(g.RecoveryConflicts)))
+	for _, conflict := range g.RecoveryConflicts {
+		sb.WriteString(conflict.String())
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString(fmt.Sprintf("unreachable rules: %d\n", len(g.Unreachable)))
+	for _, lhs := range g.Unreachable {
+		sb.WriteString("\t" + lhs + "\n")
+	}
+
+	writeSets := func(title string, sets map[string][]string) {
+		sb.WriteString(title + ":\n")
+		symbols := make([]string, 0, len(sets))
+		for symbol := range sets {
+			symbols = append(symbols, symbol)
+		}
+		sort.Strings(symbols)
+		for _, symbol := range symbols {
+			sb.WriteString(fmt.Sprintf("\t%s: %s\n", symbol, strings.Join(sets[symbol], ", ")))
+		}
+	}
+	writeSets("FIRST", g.First)
+	writeSets("FOLLOW", g.Follow)
+
+	return sb.String()
+}
+
+// GrammarBNF renders the grammar the parser is generated from in BNF.
+// Terminals are quoted, nonterminals are enclosed in angle brackets
+func GrammarBNF() string {
+	return jsonAutomaton().bnf()
+}
+
+// GrammarEBNF renders the grammar the parser is generated from in ISO 14977 EBNF.
+// Left-recursive lists become repetitions and productions that only differ
+// in some symbols being left out become optional parts
+func GrammarEBNF() string {
+	return jsonAutomaton().ebnf()
+}
+
+func (a *lrAutomaton) bnfSymbol(symbol elementType) string {
+	if a.isNonterminal(symbol) {
+		return symbol
+	}
+	return fmt.Sprintf("%q", symbol)
+}
+
+func (a *lrAutomaton) productionsOf(lhs elementType) [][]elementType {
+	var productions [][]elementType
+	for _, rule := range a.rules {
+		if rule.lhs == lhs {
+			productions = append(productions, rule.rhs...)
+		}
+	}
+	return productions
+}
+
+func (a *lrAutomaton) bnf() string {
+	var sb strings.Builder
+	for _, lhs := range a.nonterminals() {
+		indent := strings.Repeat(" ", len(lhs)+3)
+		for i, production := range a.productionsOf(lhs) {
+			symbols := make([]string, len(production))
+			for j, symbol := range production {
+				symbols[j] = a.bnfSymbol(symbol)
+			}
+			if i == 0 {
+				sb.WriteString(lhs + " ::= ")
+			} else {
+				sb.WriteString(indent[:len(indent)-2] + "| ")
+			}
+			sb.WriteString(strings.Join(symbols, " ") + "\n")
+		}
+	}
+	return sb.String()
+}
+
+// ebnfSymbol turns nonterminals into identifiers, e.g. <object fields> into object_fields
+func (a *lrAutomaton) ebnfSymbol(symbol elementType) string {
+	if a.isNonterminal(symbol) {
+		return strings.ReplaceAll(strings.Trim(symbol, "<>"), " ", "_")
+	}
+	return fmt.Sprintf("%q", symbol)
+}
+
+func (a *lrAutomaton) ebnfSequence(production []elementType, optional map[int]bool) string {
+	symbols := make([]string, len(production))
+	for i, symbol := range production {
+		symbols[i] = a.ebnfSymbol(symbol)
+		if optional[i] {
+			symbols[i] = "[ " + symbols[i] + " ]"
+		}
+	}
+	return strings.Join(symbols, " , ")
+}
+
+func (a *lrAutomaton) ebnfAlternatives(productions [][]elementType) string {
+	if production, optional, ok := optionalParts(productions); ok {
+		return a.ebnfSequence(production, optional)
+	}
+	alternatives := make([]string, len(productions))
+	for i, production := range productions {
+		alternatives[i] = a.ebnfSequence(production, nil)
+	}
+	return strings.Join(alternatives, " | ")
+}
+
+func (a *lrAutomaton) ebnf() string {
+	var sb strings.Builder
+	for _, lhs := range a.nonterminals() {
+		// lhs ::= lhs tail | base  is the same as  lhs = base , { tail }
+		var bases, tails [][]elementType
+		for _, production := range a.productionsOf(lhs) {
+			if production[0] == lhs && len(production) > 1 {
+				tails = append(tails, production[1:])
+			} else {
+				bases = append(bases, production)
+			}
+		}
+
+		definition := a.ebnfAlternatives(bases)
+		if len(tails) > 0 {
+			if len(bases) > 1 {
+				definition = "( " + definition + " )"
+			}
+			definition += " , { " + a.ebnfAlternatives(tails) + " }"
+		}
+		sb.WriteString(fmt.Sprintf("%s = %s ;\n", a.ebnfSymbol(lhs), definition))
+	}
+	return sb.String()
+}
+
+// optionalParts checks if the productions are the longest one of them with every
+// combination of some of its symbols left out. If so, it returns the longest
+// production and the positions of the symbols that can be left out
+func optionalParts(productions [][]elementType) ([]elementType, map[int]bool, bool) {
+	if len(productions) < 2 {
+		return nil, nil, false
+	}
+
+	longest := productions[0]
+	for _, production := range productions {
+		if len(production) > len(longest) {
+			longest = production
+		}
+	}
+
+	optional := map[int]bool{}
+	combinations := map[string]bool{}
+	for _, production := range productions {
+		// match the production against the longest one to find the symbols it leaves out
+		var missing []int
+		j := 0
+		for i, symbol := range longest {
+			if j < len(production) && production[j] == symbol {
+				j++
+			} else {
+				missing = append(missing, i)
+				optional[i] = true
+			}
+		}
+		if j != len(production) {
+			return nil, nil, false
+		}
+		combinations[fmt.Sprint(missing)] = true
+	}
+
+	if len(optional) >= len(longest) || len(combinations) != len(productions) || len(productions) != 1<<len(optional) {
+		return nil, nil, false
+	}
+	return longest, optional, true
+}
@@ -0,0 +1,128 @@
+package gojson
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGrammarHasNoConflicts(t *testing.T) {
+	analysis := AnalyzeGrammar()
+
+	if len(analysis.Conflicts) != 0 {
+		t.Fatalf("expected no conflicts, got: %v", analysis.Conflicts)
+	}
+	// the tables the parser runs on resolve conflicts silently
+	if len(automaton.conflicts) != 0 || len(analysis.RecoveryConflicts) != 0 {
+		t.Fatalf("expected no conflicts with error recovery, got: %v", automaton.conflicts)
+	}
+	if len(analysis.Unreachable) != 0 {
+		t.Fatalf("expected no unreachable rules, got: %v", analysis.Unreachable)
+	}
+
+	expectedFirst := []string{ltArrayStart}
+	if !reflect.DeepEqual(analysis.First[array], expectedFirst) {
+		t.Fatalf("expected FIRST(%s): %v, got: %v", array, expectedFirst, analysis.First[array])
+	}
+
+	expectedFirst = []string{ltString}
+	if !reflect.DeepEqual(analysis.First[member], expectedFirst) {
+		t.Fatalf("expected FIRST(%s): %v, got: %v", member, expectedFirst, analysis.First[member])
+	}
+
+	expectedFollow := []string{ltComma, ltObjectEnd}
+	if !reflect.DeepEqual(analysis.Follow[member], expectedFollow) {
+		t.Fatalf("expected FOLLOW(%s): %v, got: %v", member, expectedFollow, analysis.Follow[member])
+	}
+}
+
+func TestGrammarConflicts(t *testing.T) {
+	var data = []struct {
+		name     string
+		rules    []grammarRule
+		expected ConflictKind
+	}{
+		{
+			name: "ambiguous operator",
+			rules: []grammarRule{
+				{"<sum>", [][]elementType{{"<sum>", "+", "<sum>"}, {"n"}}, nil},
+			},
+			expected: ShiftReduceConflict,
+		},
+		{
+			name: "two ways to reduce",
+			rules: []grammarRule{
+				{"<value>", [][]elementType{{"<a>"}, {"<b>"}}, nil},
+				{"<a>", [][]elementType{{"n"}}, nil},
+				{"<b>", [][]elementType{{"n"}}, nil},
+			},
+			expected: ReduceReduceConflict,
+		},
+	}
+
+	for _, data := range data {
+		t.Run(data.name, func(t *testing.T) {
+			analysis := buildAutomaton(data.rules, data.rules[0].lhs).analyze()
+			if len(analysis.Conflicts) == 0 {
+				t.Fatalf("expected a %s conflict", data.expected)
+			}
+			conflict := analysis.Conflicts[0]
+			if conflict.Kind != data.expected {
+				t.Fatalf("expected: %s, got: %v", data.expected, conflict)
+			}
+			if len(conflict.Items) < 2 {
+				t.Fatalf("expected the competing items, got: %v", conflict.Items)
+			}
+		})
+	}
+}
+
+func TestUnreachableRules(t *testing.T) {
+	rules := []grammarRule{
+		{"<value>", [][]elementType{{"n"}}, nil},
+		{"<orphan>", [][]elementType{{"<value>", "n"}}, nil},
+	}
+
+	analysis := buildAutomaton(rules, "<value>").analyze()
+
+	if !reflect.DeepEqual(analysis.Unreachable, []string{"<orphan>"}) {
+		t.Fatalf("expected: [<orphan>], got: %v", analysis.Unreachable)
+	}
+}
+
+func TestGrammarEBNF(t *testing.T) {
+	ebnf := GrammarEBNF()
+
+	var expected = []string{
+		`object = "{" , [ object_fields ] , "}" ;`,
+		`object_fields = object_field , { "," , object_field } ;`,
+		`number = integer , [ fraction ] , [ exponent ] ;`,
+		`exponent = "e/E" , [ "+/-" ] , "[0-9] (digits)" ;`,
+	}
+	for _, line := range expected {
+		if !strings.Contains(ebnf, line+"\n") {
+			t.Fatalf("expected %q in:\n%s", line, ebnf)
+		}
+	}
+	if strings.Contains(ebnf, ltInvalid) {
+		t.Fatalf("expected no placeholders of malformed input in:\n%s", ebnf)
+	}
+}
+
+func TestGrammarBNF(t *testing.T) {
+	bnf := GrammarBNF()
+
+	expected := "<array> ::= \"[\" \"]\"\n" +
+		"        | \"[\" <array elements> \"]\"\n"
+	if !strings.Contains(bnf, expected) {
+		t.Fatalf("expected %q in:\n%s", expected, bnf)
+	}
+
+	expected = "<object field> ::= \"<string_literal>\" \":\" <value>\n<"
+	if !strings.Contains(bnf, expected) {
+		t.Fatalf("expected %q in:\n%s", expected, bnf)
+	}
+	if strings.Contains(bnf, ltInvalid) {
+		t.Fatalf("expected no placeholders of malformed input in:\n%s", bnf)
+	}
+}
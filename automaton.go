@@ -0,0 +1,552 @@
+package gojson
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// startRule is the index of the augmented rule <start> -> <value>,
+// which is not part of the grammar slice itself
+const startRule = -1
+
+// lrItem is a canonical LR(1) item: a production of a rule with a dot
+// marking how much of it has been seen, and the terminal that can follow it
+type lrItem struct {
+	rule       int
+	production int
+	dot        int
+	lookahead  elementType
+}
+
+type lrActionType = uint8
+
+const (
+	lrShift  lrActionType = 1
+	lrReduce lrActionType = 2
+	lrAccept lrActionType = 3
+)
+
+type lrAction struct {
+	kind       lrActionType
+	state      int // the state to go to when shifting
+	rule       int // the rule to reduce by
+	production int // the production of the rule to reduce by
+}
+
+// lrAutomaton holds the action and goto tables compiled from a grammar
+type lrAutomaton struct {
+	rules []grammarRule
+	start elementType // the nonterminal the augmented start rule derives
+
+	actions []map[elementType]lrAction
+	gotos   []map[elementType]int
+	// first maps every nonterminal to the terminals it can start with
+	first map[elementType]map[elementType]bool
+	// states keeps the items of every state, so that conflicts can be explained
+	states    [][]lrItem
+	conflicts []GrammarConflict
+}
+
+var automaton = buildAutomaton(grammar, value)
+
+// rhsOf returns the symbols of the production, including the augmented start rule
+func (a *lrAutomaton) rhsOf(rule, production int) []elementType {
+	if rule == startRule {
+		return []elementType{a.start}
+	}
+	return a.rules[rule].rhs[production]
+}
+
+func (a *lrAutomaton) lhsOf(rule int) elementType {
+	if rule == startRule {
+		return "<start>"
+	}
+	return a.rules[rule].lhs
+}
+
+func (a *lrAutomaton) isNonterminal(symbol elementType) bool {
+	for _, rule := range a.rules {
+		if rule.lhs == symbol {
+			return true
+		}
+	}
+	return false
+}
+
+// firstSets computes the terminals each nonterminal can start with.
+// None of the productions are empty, so only the first symbol of each matters
+func (a *lrAutomaton) firstSets() map[elementType]map[elementType]bool {
+	first := map[elementType]map[elementType]bool{}
+	for _, rule := range a.rules {
+		first[rule.lhs] = map[elementType]bool{}
+	}
+
+	for changed := true; changed; {
+		changed = false
+		for _, rule := range a.rules {
+			for _, production := range rule.rhs {
+				symbol := production[0]
+				candidates := map[elementType]bool{symbol: true}
+				if a.isNonterminal(symbol) {
+					candidates = first[symbol]
+				}
+				for terminal := range candidates {
+					if !first[rule.lhs][terminal] {
+						first[rule.lhs][terminal] = true
+						changed = true
+					}
+				}
+			}
+		}
+	}
+	return first
+}
+
+func (a *lrAutomaton) closure(items []lrItem) []lrItem {
+	seen := map[lrItem]bool{}
+	for _, item := range items {
+		seen[item] = true
+	}
+
+	for i := 0; i < len(items); i++ {
+		item := items[i]
+		rhs := a.rhsOf(item.rule, item.production)
+		if item.dot >= len(rhs) || !a.isNonterminal(rhs[item.dot]) {
+			continue
+		}
+
+		// the terminals that can follow the nonterminal after the dot
+		lookaheads := map[elementType]bool{item.lookahead: true}
+		if item.dot+1 < len(rhs) {
+			next := rhs[item.dot+1]
+			lookaheads = map[elementType]bool{next: true}
+			if a.isNonterminal(next) {
+				lookaheads = a.first[next]
+			}
+		}
+
+		for r, rule := range a.rules {
+			if rule.lhs != rhs[item.dot] {
+				continue
+			}
+			for p := range rule.rhs {
+				for lookahead := range lookaheads {
+					candidate := lrItem{r, p, 0, lookahead}
+					if !seen[candidate] {
+						seen[candidate] = true
+						items = append(items, candidate)
+					}
+				}
+			}
+		}
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		x, y := items[i], items[j]
+		if x.rule != y.rule {
+			return x.rule < y.rule
+		}
+		if x.production != y.production {
+			return x.production < y.production
+		}
+		if x.dot != y.dot {
+			return x.dot < y.dot
+		}
+		return x.lookahead < y.lookahead
+	})
+	return items
+}
+
+func stateKey(items []lrItem) string {
+	var sb strings.Builder
+	for _, item := range items {
+		sb.WriteString(fmt.Sprintf("%d/%d/%d/%s;", item.rule, item.production, item.dot, item.lookahead))
+	}
+	return sb.String()
+}
+
+// buildAutomaton compiles the rules into canonical LR(1) tables, with the given nonterminal
+// as the start symbol. Conflicts are recorded rather than rejected: shifting wins
+// over reducing, and the production that comes first in the rules wins between reductions
+func buildAutomaton(rules []grammarRule, start elementType) *lrAutomaton {
+	a := &lrAutomaton{rules: rules, start: start}
+	a.first = a.firstSets()
+
+	a.states = [][]lrItem{a.closure([]lrItem{{startRule, 0, 0, ltEnd}})}
+	indices := map[string]int{stateKey(a.states[0]): 0}
+
+	for s := 0; s < len(a.states); s++ {
+		actions := map[elementType]lrAction{}
+		gotos := map[elementType]int{}
+
+		// group the items by the symbol after the dot to find the transitions
+		var symbols []elementType
+		advanced := map[elementType][]lrItem{}
+		for _, item := range a.states[s] {
+			rhs := a.rhsOf(item.rule, item.production)
+			if item.dot == len(rhs) {
+				reduction := lrAction{kind: lrReduce, rule: item.rule, production: item.production}
+				if item.rule == startRule {
+					reduction = lrAction{kind: lrAccept}
+				}
+				if existing, exists := actions[item.lookahead]; !exists {
+					actions[item.lookahead] = reduction
+				} else if existing != reduction {
+					a.conflict(ReduceReduceConflict, s, item.lookahead)
+				}
+				continue
+			}
+			symbol := rhs[item.dot]
+			if _, ok := advanced[symbol]; !ok {
+				symbols = append(symbols, symbol)
+			}
+			advanced[symbol] = append(advanced[symbol], lrItem{item.rule, item.production, item.dot + 1, item.lookahead})
+		}
+
+		for _, symbol := range symbols {
+			next := a.closure(advanced[symbol])
+			key := stateKey(next)
+			target, ok := indices[key]
+			if !ok {
+				target = len(a.states)
+				indices[key] = target
+				a.states = append(a.states, next)
+			}
+
+			if a.isNonterminal(symbol) {
+				gotos[symbol] = target
+			} else {
+				if _, exists := actions[symbol]; exists {
+					a.conflict(ShiftReduceConflict, s, symbol)
+				}
+				actions[symbol] = lrAction{kind: lrShift, state: target}
+			}
+		}
+
+		a.actions = append(a.actions, actions)
+		a.gotos = append(a.gotos, gotos)
+	}
+
+	return a
+}
+
+// soleReduction returns the production the state reduces by,
+// if there is exactly one regardless of the lookahead
+func (a *lrAutomaton) soleReduction(state int) (lrAction, bool) {
+	var reduction lrAction
+	for _, action := range a.actions[state] {
+		if action.kind != lrReduce {
+			continue
+		}
+		if reduction.kind == lrReduce && (reduction.rule != action.rule || reduction.production != action.production) {
+			return lrAction{}, false
+		}
+		reduction = action
+	}
+	return reduction, reduction.kind == lrReduce
+}
+
+// recognizer runs tokens through the automaton. If it has options,
+// it also builds the JsonValue of the input as the rules are reduced
+type recognizer struct {
+	states  []int
+	symbols []elementType
+	starts  []int // the index in output of the first token of each symbol
+
+	// keepTokens makes the recognizer collect every token it shifts in output
+	keepTokens bool
+	output     []token
+	// unsyncable maps sync terminals to the number of symbols at the bottom of the stack
+	// an invalid node cannot be inserted after to reach them, see resync
+	unsyncable map[elementType]int
+
+	opts   *ParseOptions
+	errs   *[]*Error // collects the errors of the reductions instead of failing, if set
+	values []*stackElement
+
+	tracer Tracer
+	input  string // the input the tokens come from, for the tracer and the tree
+	// inputOffset is the offset input starts at, when it is only the part of a stream the tokens come from
+	inputOffset int
+
+	// keepTree makes the recognizer build the derivation tree in nodes
+	keepTree bool
+	nodes    []*ParseTreeNode
+}
+
+func newRecognizer() *recognizer {
+	return &recognizer{states: []int{0}}
+}
+
+func tokenAt(tokens []token, i int, inputLength int) token {
+	if i < len(tokens) {
+		return tokens[i]
+	}
+	return token{tokenType: ltEnd, start: inputLength, end: inputLength}
+}
+
+// accepts checks if the terminal can continue the tokens seen so far.
+// Canonical LR(1) tables detect an error before performing any reduction,
+// so looking at the current state is enough
+func (r *recognizer) accepts(terminal elementType) bool {
+	_, ok := automaton.actions[r.states[len(r.states)-1]][terminal]
+	return ok
+}
+
+// feed performs the reductions the lookahead triggers and then shifts it.
+// The lookahead must be accepted. It reports whether the json text is complete
+func (r *recognizer) feed(lookahead token) (bool, *Error) {
+	for {
+		action := automaton.actions[r.states[len(r.states)-1]][lookahead.tokenType]
+		switch action.kind {
+		case lrShift:
+			r.trace(TraceShift, lookahead, nil)
+			r.states = append(r.states, action.state)
+			r.symbols = append(r.symbols, lookahead.tokenType)
+			r.starts = append(r.starts, len(r.output))
+			if r.keepTokens {
+				r.output = append(r.output, lookahead)
+			}
+			if r.opts != nil {
+				r.values = append(r.values, &stackElement{value: lookahead})
+			}
+			if r.keepTree {
+				r.shiftNode(lookahead)
+			}
+			return false, nil
+		case lrReduce:
+			r.trace(TraceReduce, lookahead, func(step *TraceStep) {
+				step.Rule = automaton.lhsOf(action.rule)
+				step.Production = automaton.rhsOf(action.rule, action.production)
+			})
+			if err := r.reduce(action); err != nil {
+				return false, err
+			}
+		case lrAccept:
+			r.trace(TraceAccept, lookahead, nil)
+			return true, nil
+		}
+	}
+}
+
+func (r *recognizer) reduce(action lrAction) *Error {
+	size := len(automaton.rhsOf(action.rule, action.production))
+
+	if r.opts != nil {
+		values, err := reduceValues(r.values, action, r.opts, r.errs)
+		if err != nil {
+			return err
+		}
+		r.values = values
+	}
+
+	start := r.starts[len(r.starts)-size]
+	r.pop(len(r.symbols) - size)
+
+	lhs := automaton.lhsOf(action.rule)
+	if r.keepTree {
+		r.reduceNodes(lhs, size)
+	}
+	r.states = append(r.states, automaton.gotos[r.states[len(r.states)-1]][lhs])
+	r.symbols = append(r.symbols, lhs)
+	r.starts = append(r.starts, start)
+	return nil
+}
+
+// pop removes symbols until only size of them are left
+func (r *recognizer) pop(size int) {
+	r.states = r.states[:size+1]
+	r.symbols = r.symbols[:size]
+	r.starts = r.starts[:size]
+	// what has been learnt about the symbols that are gone does not hold for the ones replacing them
+	for sync, unsyncable := range r.unsyncable {
+		if unsyncable > size {
+			r.unsyncable[sync] = size
+		}
+	}
+}
+
+// truncate works like pop, but discards the tokens of the removed symbols as well.
+// It is only meant for recognizers that do not build values
+func (r *recognizer) truncate(size int) {
+	if size < len(r.symbols) {
+		r.output = r.output[:r.starts[size]]
+	}
+	r.pop(size)
+}
+
+// simulation runs terminals through the automaton on top of the stack of a recognizer, without changing
+// or copying the stack: the symbols it pops are only counted, and the ones it pushes are kept aside
+type simulation struct {
+	r       *recognizer
+	depth   int // the number of symbols of the recognizer that are still on the stack
+	states  []int
+	symbols []elementType
+}
+
+// simulate starts a simulation on the first depth symbols of the stack
+func (r *recognizer) simulate(depth int) *simulation {
+	return &simulation{r: r, depth: depth}
+}
+
+func (s *simulation) state() int {
+	if len(s.states) > 0 {
+		return s.states[len(s.states)-1]
+	}
+	return s.r.states[s.depth]
+}
+
+// symbol returns the symbol on top of the stack, if there is any
+func (s *simulation) symbol() (elementType, bool) {
+	if len(s.symbols) > 0 {
+		return s.symbols[len(s.symbols)-1], true
+	}
+	if s.depth > 0 {
+		return s.r.symbols[s.depth-1], true
+	}
+	return "", false
+}
+
+func (s *simulation) push(state int, symbol elementType) {
+	s.states = append(s.states, state)
+	s.symbols = append(s.symbols, symbol)
+}
+
+func (s *simulation) reduce(action lrAction) {
+	size := len(automaton.rhsOf(action.rule, action.production))
+	if size <= len(s.symbols) {
+		s.states = s.states[:len(s.states)-size]
+		s.symbols = s.symbols[:len(s.symbols)-size]
+	} else {
+		s.depth -= size - len(s.symbols)
+		s.states = s.states[:0]
+		s.symbols = s.symbols[:0]
+	}
+	lhs := automaton.lhsOf(action.rule)
+	s.push(automaton.gotos[s.state()][lhs], lhs)
+}
+
+// feed runs the terminals through the automaton one after the other,
+// and reports whether every one of them is accepted
+func (s *simulation) feed(terminals ...elementType) bool {
+	for _, terminal := range terminals {
+		for shifted := false; !shifted; {
+			action, ok := automaton.actions[s.state()][terminal]
+			if !ok {
+				return false
+			}
+			switch action.kind {
+			case lrShift:
+				s.push(action.state, terminal)
+				shifted = true
+			case lrReduce:
+				s.reduce(action)
+			case lrAccept:
+				return true
+			}
+		}
+	}
+	return true
+}
+
+// tokenNames are the user facing names of the terminals
+var tokenNames = map[elementType]string{
+	ltObjectStart:    "'{'",
+	ltObjectEnd:      "'}'",
+	ltArrayStart:     "'['",
+	ltArrayEnd:       "']'",
+	ltComma:          "','",
+	ltColon:          "':'",
+	ltFractionSymbol: "'.'",
+	ltExponent:       "exponent",
+	ltSign:           "sign",
+	ltMinus:          "number",
+	ltDigits:         "number",
+	ltBoolean:        "boolean",
+	ltNull:           "null",
+	ltString:         "string literal",
+	ltEnd:            "end of input",
+	ltInvalid:        "invalid token",
+}
+
+// terminalOrder is the order expected terminals are listed in
+var terminalOrder = []elementType{
+	ltString, ltDigits, ltMinus, ltBoolean, ltNull, ltObjectStart, ltArrayStart,
+	ltColon, ltComma, ltObjectEnd, ltArrayEnd, ltFractionSymbol, ltExponent, ltSign, ltEnd,
+}
+
+// contextNames describe where the parser is, based on the last symbol it has seen
+var contextNames = map[elementType]string{
+	ltObjectStart: "after '{'",
+	ltArrayStart:  "after '['",
+	ltComma:       "after ','",
+	ltColon:       "after ':'",
+	ltString:      "after object key",
+	member:        "after object member",
+	members:       "after object member",
+	element:       "after array element",
+	elements:      "after array element",
+	value:         "after the top-level value",
+}
+
+// unexpectedToken builds an error listing the terminals
+// that would have been accepted instead of the lookahead
+func (r *recognizer) unexpectedToken(lookahead token) *Error {
+	a := automaton
+	s := r.simulate(len(r.symbols))
+	// complete whatever the state is certain to reduce, so that the expectations
+	// are phrased in terms of the enclosing object or array rather than e.g. a number
+	for {
+		reduction, ok := a.soleReduction(s.state())
+		if !ok {
+			break
+		}
+		s.reduce(reduction)
+	}
+
+	actions := a.actions[s.state()]
+
+	var expected []string
+	listed := map[string]bool{}
+	valueStart := a.first[value]
+	if len(valueStart) > 0 {
+		startsValue := true
+		for terminal := range valueStart {
+			if _, ok := actions[terminal]; !ok {
+				startsValue = false
+			}
+		}
+		if startsValue {
+			expected = append(expected, "value")
+			for terminal := range valueStart {
+				listed[tokenNames[terminal]] = true
+			}
+		}
+	}
+	for _, terminal := range terminalOrder {
+		name := tokenNames[terminal]
+		if _, ok := actions[terminal]; ok && !listed[name] {
+			expected = append(expected, name)
+			listed[name] = true
+		}
+	}
+
+	msg := "expected " + joinAlternatives(expected)
+	if symbol, ok := s.symbol(); ok {
+		if context, ok := contextNames[symbol]; ok {
+			msg += " " + context
+		}
+	}
+	msg += ", found " + tokenNames[lookahead.tokenType]
+
+	return newError(lookahead.start, msg)
+}
+
+// joinAlternatives renders a list like "a, b or c"
+func joinAlternatives(alternatives []string) string {
+	if len(alternatives) == 1 {
+		return alternatives[0]
+	}
+	return strings.Join(alternatives[:len(alternatives)-1], ", ") + " or " + alternatives[len(alternatives)-1]
+}
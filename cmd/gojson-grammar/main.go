@@ -0,0 +1,37 @@
+// Command gojson-grammar analyzes the grammar the gojson parser is generated from.
+//
+// By default it reports the conflicts, unreachable rules and the FIRST and FOLLOW sets
+// of the grammar, and exits with status 1 if there are any conflicts, with or without
+// the productions error recovery adds to the grammar, so that it can
+// guard changes to the grammar. With -format bnf or -format ebnf it prints the grammar instead.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/rhaeguard/gojson"
+)
+
+func main() {
+	format := flag.String("format", "analysis", "what to print: analysis, bnf or ebnf")
+	flag.Parse()
+
+	switch *format {
+	case "analysis":
+		analysis := gojson.AnalyzeGrammar()
+		fmt.Print(analysis)
+		if len(analysis.Conflicts) != 0 || len(analysis.RecoveryConflicts) != 0 {
+			os.Exit(1)
+		}
+	case "bnf":
+		fmt.Print(gojson.GrammarBNF())
+	case "ebnf":
+		fmt.Print(gojson.GrammarEBNF())
+	default:
+		fmt.Fprintf(os.Stderr, "unknown format: %s\n", *format)
+		flag.Usage()
+		os.Exit(2)
+	}
+}
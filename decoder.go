@@ -0,0 +1,134 @@
+package gojson
+
+import "io"
+
+// Decoder reads and decodes json values from an input stream.
+// The stream may hold any number of top-level values one after another,
+// optionally separated by whitespace, e.g. `{"a": 1} {"a": 2} 3`.
+// The tokens are parsed as they are read, so only the values being built are kept in memory,
+// not the input they come from
+type Decoder struct {
+	tokenizer *Tokenizer
+	opts      UnmarshalOptions
+	err       error // the first error, every later call returns it as well
+}
+
+// NewDecoder returns a decoder reading from r. The decoder buffers the input,
+// so it may read more data from r than the values it has decoded
+func NewDecoder(r io.Reader) *Decoder {
+	return NewDecoderWithOptions(r, UnmarshalOptions{})
+}
+
+// NewDecoderWithOptions works like NewDecoder, but lets the caller
+// configure how the values are parsed and deserialized
+func NewDecoderWithOptions(r io.Reader, opts UnmarshalOptions) *Decoder {
+	return &Decoder{tokenizer: NewTokenizerReader(r), opts: opts}
+}
+
+// Decode reads the next json value from the stream and deserializes it
+// into the provided object, which needs to be a pointer. It returns io.EOF
+// if there are no more values in the stream
+func (d *Decoder) Decode(ptr any) error {
+	opts := d.opts.withExactNumbers()
+	json, err := d.decodeValue(&opts.Parse)
+	if err != nil {
+		return err
+	}
+	return json.UnmarshalWithOptions(ptr, opts)
+}
+
+// DecodeValue reads the next json value from the stream.
+// Unlike Parse, which checks every token before their order,
+// it reports the error that comes first in the input.
+// It returns io.EOF if there are no more values in the stream
+func (d *Decoder) DecodeValue() (JsonValue, error) {
+	return d.decodeValue(&d.opts.Parse)
+}
+
+func (d *Decoder) decodeValue(opts *ParseOptions) (JsonValue, error) {
+	if d.err != nil {
+		return JsonValue{}, d.err
+	}
+
+	json, err := d.parseValue(opts)
+	if err != nil {
+		d.err = err
+		return JsonValue{}, err
+	}
+	return json, nil
+}
+
+// parseValue feeds the tokens to the parser as they are lexed, until they make up a complete value
+func (d *Decoder) parseValue(opts *ParseOptions) (JsonValue, error) {
+	t := d.tokenizer
+	t.SetOptions(*opts)
+	stream := t.stream
+
+	r := newRecognizer()
+	r.opts = opts
+	r.tracer = opts.Tracer
+
+	// the previous token stays in the buffer until the next one has been fed,
+	// since the reductions the next one triggers may fail on it, e.g. on a number out of range
+	i := 0
+	for {
+		lexemes, end, err := t.lexNext(i)
+		if err == io.EOF {
+			if len(r.symbols) == 0 {
+				return JsonValue{}, io.EOF
+			}
+			end = len(stream.buf)
+			lexemes = []token{{tokenType: ltEnd, start: end, end: end}}
+		} else if err != nil {
+			return JsonValue{}, err
+		}
+
+		for _, lexeme := range lexemes {
+			if err := d.feed(r, lexeme, end); err != nil {
+				return JsonValue{}, err
+			}
+		}
+
+		if r.accepts(ltEnd) {
+			// nothing can be added to the value, so it is complete
+			if err := d.feed(r, token{tokenType: ltEnd, start: end, end: end}, end); err != nil {
+				return JsonValue{}, err
+			}
+			stream.consume(end)
+			// the only thing left on the stack is the <value> of the whole input
+			return r.values[0].asJsonValue(), nil
+		}
+
+		start := lexemes[0].start
+		stream.consume(start)
+		i = end - start
+	}
+}
+
+// feed runs the terminal through the recognizer. Its offsets are moved from the buffer to the stream,
+// so that they stay valid once the buffer has been consumed. end is where the token of the terminal ends in the buffer
+func (d *Decoder) feed(r *recognizer, lexeme token, end int) error {
+	stream := d.tokenizer.stream
+	lexeme.start += stream.offset
+	lexeme.end += stream.offset
+	if r.tracer != nil {
+		r.input = string(stream.buf[:end])
+		r.inputOffset = stream.offset
+	}
+
+	var err *Error
+	if r.accepts(lexeme.tokenType) {
+		_, err = r.feed(lexeme)
+	} else {
+		err = r.unexpectedToken(lexeme)
+		r.trace(TraceReject, lexeme, func(step *TraceStep) {
+			step.Err = err
+		})
+	}
+	if err == nil {
+		return nil
+	}
+	// the error is in the buffer, which holds everything from the previous token on
+	err.Offset -= stream.offset
+	return stream.locate(err.locate(string(stream.buf[:end])))
+}
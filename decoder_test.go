@@ -0,0 +1,191 @@
+package gojson
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func decodeAll(d *Decoder) ([]JsonValue, error) {
+	var values []JsonValue
+	for {
+		json, err := d.DecodeValue()
+		if err != nil {
+			return values, err
+		}
+		values = append(values, json)
+	}
+}
+
+func TestDecoder(t *testing.T) {
+	input := "{\"a\": [1, \"}]\"]} [2]3 \"x\"true\nnull  -4.5e1{}"
+	expected := []JsonValue{
+		{map[string]JsonValue{"a": {[]JsonValue{{float64(1), NUMBER}, {"}]", STRING}}, ARRAY}}, OBJECT},
+		{[]JsonValue{{float64(2), NUMBER}}, ARRAY},
+		{float64(3), NUMBER},
+		{"x", STRING},
+		{true, BOOL},
+		{nil, NULL},
+		{float64(-45), NUMBER},
+		{map[string]JsonValue{}, OBJECT},
+	}
+
+	var readers = map[string]io.Reader{
+		"whole input":   strings.NewReader(input),
+		"byte by byte":  iotest.OneByteReader(strings.NewReader(input)),
+		"half by half":  iotest.HalfReader(strings.NewReader(input)),
+		"data with eof": iotest.DataErrReader(strings.NewReader(input)),
+	}
+	for name, reader := range readers {
+		t.Run(name, func(t *testing.T) {
+			values, err := decodeAll(NewDecoder(reader))
+			if err != io.EOF {
+				t.Fatalf("expected io.EOF, got: %v", err)
+			}
+			if !reflect.DeepEqual(values, expected) {
+				t.Fatalf("expected: %v, got: %v", expected, values)
+			}
+		})
+	}
+}
+
+func TestDecoderEOF(t *testing.T) {
+	for _, input := range []string{"", " \n\t", byteOrderMark} {
+		d := NewDecoderWithOptions(strings.NewReader(input), UnmarshalOptions{Parse: ParseOptions{AllowBOM: true}})
+		if _, err := d.DecodeValue(); err != io.EOF {
+			t.Fatalf("expected io.EOF for %q, got: %v", input, err)
+		}
+	}
+}
+
+func TestDecoderLargeValue(t *testing.T) {
+	item := `{"name": "` + strings.Repeat("x", 100) + `"},`
+	input := "[" + strings.Repeat(item, 1000) + "0] 1"
+
+	d := NewDecoder(strings.NewReader(input))
+	json, err := d.DecodeValue()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if elements := json.Value.([]JsonValue); len(elements) != 1001 {
+		t.Fatalf("expected 1001 elements, got: %d", len(elements))
+	}
+
+	json, err = d.DecodeValue()
+	if err != nil || json.Value != float64(1) {
+		t.Fatalf("expected: 1, got: %v, %v", json, err)
+	}
+
+	// the tokens are parsed as they are read, the buffer only ever holds a few of them
+	if size := cap(d.tokenizer.stream.mem); size > 4*streamChunkSize {
+		t.Fatalf("expected the buffer to stay small, got: %d bytes", size)
+	}
+}
+
+func TestDecoderInto(t *testing.T) {
+	type point struct {
+		X int
+		Y int
+	}
+
+	d := NewDecoder(strings.NewReader(`{"X": 1, "Y": 2}` + "\n" + `{"X": 3, "Y": 4}`))
+	var points []point
+	for {
+		var p point
+		err := d.Decode(&p)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		points = append(points, p)
+	}
+
+	expected := []point{{1, 2}, {3, 4}}
+	if !reflect.DeepEqual(points, expected) {
+		t.Fatalf("expected: %v, got: %v", expected, points)
+	}
+}
+
+func TestDecoderErrors(t *testing.T) {
+	var data = map[string]struct {
+		input    string
+		expected string
+		snippet  string
+	}{
+		`located in the stream`:  {"1\n2 [3 4]", "expected ',' or ']' after array element, found number at 2:6", "2 | 3 4\n  |   ^"},
+		`incomplete value`:       {`{"a": 1} {"a"`, "expected ':' after object key, found end of input at 1:14", "1 | \"a\"\n  |    ^"},
+		`stray closing bracket`:  {`[1]]`, "expected value, found ']' at 1:4", "1 | ]\n  | ^"},
+		`invalid literal`:        {`1 nul`, "unrecognized token at 1:3", "1 | nul\n  | ^"},
+		`byte order mark`:        {byteOrderMark + `1`, "input starts with a byte order mark at 1:1", "1 | \ufeff\n  | ^"},
+		`unclosed string`:        {`"abc`, "string is not properly closed at 1:5", "1 | \"abc\n  |     ^"},
+		`long unclosed string`:   {`1 "` + strings.Repeat("a", 100), "string is not properly closed at 1:104", "1 | …" + strings.Repeat("a", 40) + "\n  | " + strings.Repeat(" ", 41) + "^"},
+		`number out of range`:    {"[1,\n 1e999 ]", "number out of range: 1e999 at 2:2", "2 | 1e999 ]\n  | ^"},
+		`error in a later value`: {"[1]\n[2]\n {\"a\": x}", "unrecognized token at 3:8", "3 | : x\n  |   ^"},
+	}
+
+	for name, data := range data {
+		t.Run(name, func(t *testing.T) {
+			d := NewDecoder(iotest.OneByteReader(strings.NewReader(data.input)))
+			_, err := decodeAll(d)
+
+			var e *Error
+			if !errors.As(err, &e) {
+				t.Fatalf("expected a syntax error, got: %v", err)
+			}
+			if e.Error() != data.expected {
+				t.Fatalf("expected: %s, got: %s", data.expected, e.Error())
+			}
+			if e.Snippet() != data.snippet {
+				t.Fatalf("expected snippet:\n%s\ngot:\n%s", data.snippet, e.Snippet())
+			}
+
+			// the decoder does not carry on after an error
+			if _, again := d.DecodeValue(); again != err {
+				t.Fatalf("expected the same error again, got: %v", again)
+			}
+		})
+	}
+
+	t.Run("read errors are returned", func(t *testing.T) {
+		d := NewDecoder(iotest.TimeoutReader(iotest.OneByteReader(strings.NewReader(`[1, 2, 3]`))))
+		if _, err := d.DecodeValue(); err != iotest.ErrTimeout {
+			t.Fatalf("expected: %v, got: %v", iotest.ErrTimeout, err)
+		}
+	})
+}
+
+func FuzzDecoder(f *testing.F) {
+	var seeds = []string{
+		`{"a": [1, "}]"]}`,
+		`[1, 2] 3`,
+		`"\"" 1e5`,
+		`{"a" 1}`,
+	}
+	for _, seed := range seeds {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, input string) {
+		expected, err := Parse(input)
+		if err != nil {
+			return
+		}
+
+		d := NewDecoder(iotest.HalfReader(strings.NewReader(input)))
+		json, derr := d.DecodeValue()
+		if derr != nil {
+			t.Fatalf("unexpected error: %v", derr)
+		}
+		if !reflect.DeepEqual(json, expected) {
+			t.Fatalf("expected: %v, got: %v", expected, json)
+		}
+		if _, derr := d.DecodeValue(); derr != io.EOF {
+			t.Fatalf("expected io.EOF, got: %v", derr)
+		}
+	})
+}
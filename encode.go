@@ -0,0 +1,175 @@
+package gojson
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// appendValue appends the compact json text of the value to buf
+func appendValue(buf []byte, jv JsonValue) ([]byte, error) {
+	switch jv.ValueType {
+	case STRING:
+		return appendString(buf, jv.Value.(string)), nil
+	case NUMBER:
+		return appendNumber(buf, jv.Value)
+	case BOOL:
+		return strconv.AppendBool(buf, jv.Value.(bool)), nil
+	case NULL:
+		return append(buf, "null"...), nil
+	case OBJECT:
+		members := jv.Value.(map[string]JsonValue)
+		keys := make([]string, 0, len(members))
+		for key := range members {
+			keys = append(keys, key)
+		}
+		// maps have no order, sorting the keys keeps the output deterministic
+		sort.Strings(keys)
+
+		buf = append(buf, '{')
+		for i, key := range keys {
+			if i > 0 {
+				buf = append(buf, ',')
+			}
+			buf = appendString(buf, key)
+			buf = append(buf, ':')
+			var err error
+			if buf, err = appendValue(buf, members[key]); err != nil {
+				return nil, err
+			}
+		}
+		return append(buf, '}'), nil
+	case ARRAY:
+		buf = append(buf, '[')
+		for i, element := range jv.Value.([]JsonValue) {
+			if i > 0 {
+				buf = append(buf, ',')
+			}
+			var err error
+			if buf, err = appendValue(buf, element); err != nil {
+				return nil, err
+			}
+		}
+		return append(buf, ']'), nil
+	}
+	return nil, errors.New(fmt.Sprintf("cannot serialize value of type %s", jv.ValueType))
+}
+
+// appendNumber appends numbers held either as float64 or as JsonNumber. The literal of a JsonNumber loses
+// the leading plus sign and zeros ParseOptions.LenientNumbers accepts, and has to be valid json otherwise
+func appendNumber(buf []byte, value interface{}) ([]byte, error) {
+	switch n := value.(type) {
+	case JsonNumber:
+		return appendJsonNumber(buf, JsonNumber(strictNumber(string(n))))
+	case float64:
+		return appendFloat(buf, n, 64)
+	}
+	return nil, errors.New(fmt.Sprintf("cannot serialize number of type %T", value))
+}
+
+// strictNumber drops the leading plus sign and zeros that ParseOptions.LenientNumbers accepts,
+// so that the output is valid json. Literals that do not start with digits are left as they are
+func strictNumber(literal string) string {
+	negative := strings.HasPrefix(literal, "-")
+	digits := strings.TrimPrefix(strings.TrimPrefix(literal, "-"), "+")
+	if digits == "" || !isDigit(digits[0]) {
+		return literal
+	}
+	trimmed := strings.TrimLeft(digits, "0")
+	if trimmed == "" || trimmed[0] < '0' || trimmed[0] > '9' {
+		// the integer part is a single zero
+		trimmed = "0" + trimmed
+	}
+	if negative {
+		return "-" + trimmed
+	}
+	return trimmed
+}
+
+// appendFloat appends the shortest literal that parses back to the same float of the given bit size
+func appendFloat(buf []byte, f float64, bits int) ([]byte, error) {
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return nil, errors.New(fmt.Sprintf("cannot serialize number: %v", f))
+	}
+
+	// the exponent is only used for very large and very small numbers, like javascript does
+	format := byte('f')
+	if abs := math.Abs(f); abs != 0 && (bits == 64 && (abs < 1e-6 || abs >= 1e21) ||
+		bits == 32 && (float32(abs) < 1e-6 || float32(abs) >= 1e21)) {
+		format = 'e'
+	}
+	buf = strconv.AppendFloat(buf, f, format, -1, bits)
+
+	if format == 'e' {
+		// 1e-07 is written as 1e-7
+		n := len(buf)
+		if n >= 4 && buf[n-4] == 'e' && buf[n-3] == '-' && buf[n-2] == '0' {
+			buf[n-2] = buf[n-1]
+			buf = buf[:n-1]
+		}
+	}
+	return buf, nil
+}
+
+const hexDigits = "0123456789abcdef"
+
+// appendString appends the string as a quoted json string literal
+func appendString(buf []byte, s string) []byte {
+	return appendQuoted(buf, s, false)
+}
+
+// appendQuoted appends the string as a quoted json string literal, writing invalid UTF-8 bytes as \ufffd.
+// If asciiOnly is set, the characters outside of ASCII are written as \uXXXX escapes,
+// using surrogate pairs where needed
+func appendQuoted(buf []byte, s string, asciiOnly bool) []byte {
+	buf = append(buf, '"')
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		switch {
+		case ch == '"' || ch == '\\':
+			buf = append(buf, '\\', ch)
+		case ch == '\n':
+			buf = append(buf, '\\', 'n')
+		case ch == '\r':
+			buf = append(buf, '\\', 'r')
+		case ch == '\t':
+			buf = append(buf, '\\', 't')
+		case ch == '\b':
+			buf = append(buf, '\\', 'b')
+		case ch == '\f':
+			buf = append(buf, '\\', 'f')
+		case ch < 0x20:
+			buf = append(buf, '\\', 'u', '0', '0', hexDigits[ch>>4], hexDigits[ch&0xf])
+		case ch >= utf8.RuneSelf:
+			r, size := utf8.DecodeRuneInString(s[i:])
+			switch {
+			case r == utf8.RuneError && size == 1:
+				// invalid bytes, including the lone surrogates of SurrogateWTF8, are not valid json
+				buf = appendRuneEscape(buf, utf8.RuneError)
+			case !asciiOnly:
+				// the bytes of multi-byte characters are kept as they are
+				buf = append(buf, s[i:i+size]...)
+			default:
+				if r1, r2 := utf16.EncodeRune(r); r1 != utf8.RuneError {
+					buf = appendRuneEscape(appendRuneEscape(buf, r1), r2)
+				} else {
+					buf = appendRuneEscape(buf, r)
+				}
+			}
+			i += size - 1
+		default:
+			buf = append(buf, ch)
+		}
+	}
+	return append(buf, '"')
+}
+
+// appendRuneEscape appends the \uXXXX escape of a rune of the basic multilingual plane
+func appendRuneEscape(buf []byte, r rune) []byte {
+	return append(buf, '\\', 'u', hexDigits[r>>12&0xf], hexDigits[r>>8&0xf], hexDigits[r>>4&0xf], hexDigits[r&0xf])
+}
@@ -0,0 +1,179 @@
+package gojson
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Encoder writes json values to an output stream as they are produced, without keeping them in memory.
+// Objects and arrays can be written piece by piece with WriteStartObject, WriteKey, WriteEndObject and so on,
+// and the encoder rejects the calls that would make the output invalid, e.g. a key outside of an object.
+// Every top-level value is followed by a new line. Every call writes to the stream,
+// so wrapping it in a bufio.Writer helps when there are many small values
+type Encoder struct {
+	writer io.Writer
+	buf    []byte
+
+	// objects tells for every object and array open if it is an object
+	objects []bool
+	// empty is set while nothing has been written into the innermost object or array
+	empty bool
+	// keyed is set when a key has been written, but not its value
+	keyed bool
+	err   error // the first write error, every later call returns it as well
+}
+
+// NewEncoder returns an encoder writing to w
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{writer: w}
+}
+
+// Encode serializes the object with Marshal and writes it as the next value
+func (e *Encoder) Encode(v any) error {
+	return e.writeValue(func(buf []byte) ([]byte, error) {
+		return marshal(buf, v)
+	})
+}
+
+// WriteValue writes the value in its compact form as the next value
+func (e *Encoder) WriteValue(jv JsonValue) error {
+	return e.writeValue(func(buf []byte) ([]byte, error) {
+		return appendValue(buf, jv)
+	})
+}
+
+// WriteStartObject starts an object as the next value
+func (e *Encoder) WriteStartObject() error {
+	return e.writeStart(true)
+}
+
+// WriteEndObject ends the innermost object, which needs to be open and not waiting for the value of a key
+func (e *Encoder) WriteEndObject() error {
+	return e.writeEnd(true)
+}
+
+// WriteStartArray starts an array as the next value
+func (e *Encoder) WriteStartArray() error {
+	return e.writeStart(false)
+}
+
+// WriteEndArray ends the innermost array, which needs to be open
+func (e *Encoder) WriteEndArray() error {
+	return e.writeEnd(false)
+}
+
+// WriteKey writes the key of the next member of the innermost object.
+// The value of the member is the next value written
+func (e *Encoder) WriteKey(key string) error {
+	if e.err != nil {
+		return e.err
+	}
+	if len(e.objects) == 0 || !e.objects[len(e.objects)-1] {
+		return errors.New(fmt.Sprintf("key %q outside of an object", key))
+	}
+	if e.keyed {
+		return errors.New(fmt.Sprintf("key %q written where a value is expected", key))
+	}
+
+	buf := e.buf[:0]
+	if !e.empty {
+		buf = append(buf, ',')
+	}
+	buf = appendString(buf, key)
+	buf = append(buf, ':')
+	e.keyed = true
+	return e.write(buf)
+}
+
+// Depth returns the number of objects and arrays that are open
+func (e *Encoder) Depth() int {
+	return len(e.objects)
+}
+
+// valuePrefix checks if a value can be written next and appends what needs to precede it
+func (e *Encoder) valuePrefix(buf []byte) ([]byte, error) {
+	if len(e.objects) == 0 {
+		return buf, nil
+	}
+	if e.objects[len(e.objects)-1] {
+		if !e.keyed {
+			return nil, errors.New("value written in an object without a key")
+		}
+		return buf, nil
+	}
+	if !e.empty {
+		buf = append(buf, ',')
+	}
+	return buf, nil
+}
+
+func (e *Encoder) writeValue(serialize func(buf []byte) ([]byte, error)) error {
+	if e.err != nil {
+		return e.err
+	}
+	buf, err := e.valuePrefix(e.buf[:0])
+	if err != nil {
+		return err
+	}
+	// nothing is written if the value cannot be serialized
+	if buf, err = serialize(buf); err != nil {
+		return err
+	}
+	return e.write(e.endValue(buf))
+}
+
+// endValue updates the state after a value, and ends the top-level values with a new line
+func (e *Encoder) endValue(buf []byte) []byte {
+	e.empty = false
+	e.keyed = false
+	if len(e.objects) == 0 {
+		buf = append(buf, '\n')
+	}
+	return buf
+}
+
+func (e *Encoder) writeStart(isObject bool) error {
+	if e.err != nil {
+		return e.err
+	}
+	buf, err := e.valuePrefix(e.buf[:0])
+	if err != nil {
+		return err
+	}
+	start := byte('[')
+	if isObject {
+		start = '{'
+	}
+	e.objects = append(e.objects, isObject)
+	e.empty = true
+	e.keyed = false
+	return e.write(append(buf, start))
+}
+
+func (e *Encoder) writeEnd(isObject bool) error {
+	if e.err != nil {
+		return e.err
+	}
+	kind, end := "array", byte(']')
+	if isObject {
+		kind, end = "object", '}'
+	}
+	if len(e.objects) == 0 || e.objects[len(e.objects)-1] != isObject {
+		return errors.New(fmt.Sprintf("no %s to end", kind))
+	}
+	if e.keyed {
+		return errors.New("object ended without the value of its last key")
+	}
+
+	e.objects = e.objects[:len(e.objects)-1]
+	return e.write(e.endValue(append(e.buf[:0], end)))
+}
+
+func (e *Encoder) write(buf []byte) error {
+	e.buf = buf
+	if _, err := e.writer.Write(buf); err != nil {
+		e.err = err
+	}
+	return e.err
+}
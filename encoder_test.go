@@ -0,0 +1,135 @@
+package gojson
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestEncoder(t *testing.T) {
+	var out bytes.Buffer
+	e := NewEncoder(&out)
+
+	steps := []func() error{
+		e.WriteStartObject,
+		func() error { return e.WriteKey("name") },
+		func() error { return e.Encode("gojson") },
+		func() error { return e.WriteKey("items") },
+		e.WriteStartArray,
+		func() error { return e.Encode(1) },
+		e.WriteStartObject,
+		e.WriteEndObject,
+		e.WriteStartArray,
+		e.WriteEndArray,
+		func() error { return e.WriteValue(JsonValue{map[string]JsonValue{"ok": {true, BOOL}}, OBJECT}) },
+		e.WriteEndArray,
+		func() error { return e.WriteKey("empty") },
+		e.WriteStartObject,
+		e.WriteEndObject,
+		e.WriteEndObject,
+		func() error { return e.Encode([]int{1, 2}) },
+		func() error { return e.Encode(nil) },
+	}
+	for i, step := range steps {
+		if err := step(); err != nil {
+			t.Fatalf("unexpected error at step %d: %v", i, err)
+		}
+	}
+
+	expected := "{\"name\":\"gojson\",\"items\":[1,{},[],{\"ok\":true}],\"empty\":{}}\n[1,2]\nnull\n"
+	if out.String() != expected {
+		t.Fatalf("expected: %q, got: %q", expected, out.String())
+	}
+	if e.Depth() != 0 {
+		t.Fatalf("expected no open objects or arrays, got: %d", e.Depth())
+	}
+
+	values, err := decodeAll(NewDecoder(&out))
+	if err != io.EOF || len(values) != 3 {
+		t.Fatalf("expected 3 values, got: %v, %v", values, err)
+	}
+}
+
+func TestEncoderStructure(t *testing.T) {
+	var data = map[string]struct {
+		steps    func(e *Encoder) error
+		expected string
+	}{
+		`key outside of an object`: {func(e *Encoder) error {
+			return e.WriteKey("a")
+		}, `key "a" outside of an object`},
+		`key in an array`: {func(e *Encoder) error {
+			_ = e.WriteStartArray()
+			return e.WriteKey("a")
+		}, `key "a" outside of an object`},
+		`two keys in a row`: {func(e *Encoder) error {
+			_ = e.WriteStartObject()
+			_ = e.WriteKey("a")
+			return e.WriteKey("b")
+		}, `key "b" written where a value is expected`},
+		`value without a key`: {func(e *Encoder) error {
+			_ = e.WriteStartObject()
+			return e.Encode(1)
+		}, "value written in an object without a key"},
+		`object without a key`: {func(e *Encoder) error {
+			_ = e.WriteStartObject()
+			return e.WriteStartObject()
+		}, "value written in an object without a key"},
+		`missing value`: {func(e *Encoder) error {
+			_ = e.WriteStartObject()
+			_ = e.WriteKey("a")
+			return e.WriteEndObject()
+		}, "object ended without the value of its last key"},
+		`unbalanced end`: {func(e *Encoder) error {
+			return e.WriteEndArray()
+		}, "no array to end"},
+		`mismatched end`: {func(e *Encoder) error {
+			_ = e.WriteStartArray()
+			return e.WriteEndObject()
+		}, "no object to end"},
+		`unsupported value`: {func(e *Encoder) error {
+			_ = e.WriteStartArray()
+			return e.Encode(make(chan int))
+		}, "unsupported type: chan int"},
+		`invalid number literal`: {func(e *Encoder) error {
+			_ = e.WriteStartArray()
+			return e.WriteValue(JsonValue{JsonNumber("abc"), NUMBER})
+		}, `invalid number literal: "abc"`},
+	}
+
+	for name, data := range data {
+		t.Run(name, func(t *testing.T) {
+			var out bytes.Buffer
+			e := NewEncoder(&out)
+			err := data.steps(e)
+			if err == nil || err.Error() != data.expected {
+				t.Fatalf("expected: %s, got: %v", data.expected, err)
+			}
+
+			// the rejected call writes nothing, the encoder can carry on
+			before := out.Len()
+			_ = e.Encode(true)
+			if e.Depth() == 0 && out.String()[before:] != "true\n" {
+				t.Fatalf("expected the encoder to carry on, got: %q", out.String())
+			}
+		})
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestEncoderWriteError(t *testing.T) {
+	e := NewEncoder(failingWriter{})
+	if err := e.WriteStartArray(); err == nil || err.Error() != "disk full" {
+		t.Fatalf("expected the write error, got: %v", err)
+	}
+	// the write error is returned by every later call
+	if err := e.Encode(1); err == nil || err.Error() != "disk full" {
+		t.Fatalf("expected the write error, got: %v", err)
+	}
+}
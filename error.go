@@ -1,19 +1,173 @@
 package gojson
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
 
+// Error describes a problem found in the json input.
+// Offset is the byte offset of the problem in the input, while Line and Column
+// are its 1-based human friendly location. Column counts characters, not bytes.
+// Offset is -1 if the error does not refer to a specific location
 type Error struct {
-	Pos int
-	Msg string
+	Offset int
+	// Pos holds the same value as Offset.
+	//
+	// Deprecated: use Offset instead
+	Pos    int
+	Line   int
+	Column int
+	Msg    string
+	source string // the part of the line of the input around the error
+	// sourceColumn is the column source starts at, if it is not the start of the line
+	sourceColumn int
 }
 
+// snippetWidth is the number of characters the snippet of an error shows
+// on either side of it. The rest of the line is left out, so that minified input
+// is not rendered, nor kept alive by the error, in full
+const snippetWidth = 40
+
 func (se *Error) Error() string {
-	if se.Pos == -1 {
+	if se.Offset == -1 {
 		return se.Msg
 	}
-	return fmt.Sprintf("%s at position %d", se.Msg, se.Pos)
+	if se.Line == 0 {
+		return fmt.Sprintf("%s at offset %d", se.Msg, se.Offset)
+	}
+	return fmt.Sprintf("%s at %d:%d", se.Msg, se.Line, se.Column)
+}
+
+// Snippet renders the line of the input containing the error
+// with a caret pointing at the exact location, e.g.
+//
+//	3 | "hello": nill
+//	  |          ^
+//
+// Long lines are cut to the characters around the error, and an ellipsis marks what is left out.
+// It returns an empty string if the location of the error is not known
+func (se *Error) Snippet() string {
+	if se.Offset == -1 || se.Line == 0 {
+		return ""
+	}
+
+	gutter := fmt.Sprintf("%d | ", se.Line)
+	var sb strings.Builder
+	sb.WriteString(gutter)
+	sb.WriteString(se.source)
+	sb.WriteByte('\n')
+	sb.WriteString(strings.Repeat(" ", len(gutter)-2))
+	sb.WriteString("| ")
+	column := 1
+	if se.sourceColumn > 1 {
+		column = se.sourceColumn
+	}
+	for _, ch := range se.source {
+		if column == se.Column {
+			break
+		}
+		// tabs are kept so that the caret lines up with the source
+		if ch == '\t' {
+			sb.WriteRune(ch)
+		} else {
+			sb.WriteByte(' ')
+		}
+		column++
+	}
+	sb.WriteByte('^')
+	return sb.String()
+}
+
+// locate fills in the line and column of the error from the input it refers to
+func (se *Error) locate(input string) *Error {
+	if se.Offset < 0 || se.Offset > len(input) {
+		return se
+	}
+
+	lineStart := strings.LastIndexByte(input[:se.Offset], '\n') + 1
+	line := strings.Count(input[:lineStart], "\n") + 1
+	column := utf8.RuneCountInString(input[lineStart:se.Offset]) + 1
+	return se.at(input, line, column)
+}
+
+// locateAll locates the errors, sorted by their offsets, in a single pass over the input they refer to
+func locateAll(errs []*Error, input string) {
+	line, column, i := 1, 1, 0
+	for _, err := range errs {
+		if err.Offset < 0 || err.Offset > len(input) {
+			continue
+		}
+		for _, ch := range input[i:err.Offset] {
+			if ch == '\n' {
+				line++
+				column = 1
+			} else {
+				column++
+			}
+		}
+		i = err.Offset
+		err.at(input, line, column)
+	}
+}
+
+// at sets the line and column of the error, and copies the characters around it from the input,
+// so that the error does not keep the whole input alive
+func (se *Error) at(input string, line, column int) *Error {
+	se.Line = line
+	se.Column = column
+
+	start := se.Offset
+	for n := 0; n < snippetWidth && start > 0 && input[start-1] != '\n'; n++ {
+		_, size := utf8.DecodeLastRuneInString(input[:start])
+		start -= size
+	}
+	end := se.Offset
+	for n := 0; n < snippetWidth && !isLineEnd(input, end); n++ {
+		_, size := utf8.DecodeRuneInString(input[end:])
+		end += size
+	}
+
+	var sb strings.Builder
+	if start > 0 && input[start-1] != '\n' {
+		// the ellipsis takes the column of the character before the window
+		se.sourceColumn = se.Column - utf8.RuneCountInString(input[start:se.Offset]) - 1
+		sb.WriteString("…")
+	}
+	sb.WriteString(input[start:end])
+	if !isLineEnd(input, end) {
+		sb.WriteString("…")
+	}
+	se.source = sb.String()
+	return se
+}
+
+// isLineEnd checks if the line ends at the offset, which leaves out the '\r' of "\r\n"
+func isLineEnd(input string, i int) bool {
+	if i == len(input) || input[i] == '\n' {
+		return true
+	}
+	return input[i] == '\r' && (i+1 == len(input) || input[i+1] == '\n')
+}
+
+func newError(Offset int, Msg string) *Error {
+	return &Error{Offset: Offset, Pos: Offset, Msg: Msg}
+}
+
+// UnmarshalError describes why a json value
+// could not be stored in the provided object
+type UnmarshalError struct {
+	Path string
+	Msg  string
+}
+
+func (ue *UnmarshalError) Error() string {
+	if ue.Path == "" {
+		return ue.Msg
+	}
+	return fmt.Sprintf("%s: %s", ue.Path, ue.Msg)
 }
 
-func newError(Pos int, Msg string) *Error {
-	return &Error{Pos, Msg}
+func newUnmarshalError(Path string, Msg string) *UnmarshalError {
+	return &UnmarshalError{Path, Msg}
 }
@@ -0,0 +1,189 @@
+package gojson
+
+import (
+	"errors"
+	"io"
+	"strings"
+)
+
+// Handler receives the parts of a json text as the parser comes across them.
+// Returning an error from any of the callbacks aborts the parsing, and the error
+// is returned by ParseEvents as is. The exception is SkipSubtree,
+// which skips a part of the input instead
+type Handler interface {
+	StartObject() error
+	// Key is called with the key of each object member, before the events of its value
+	Key(key string) error
+	EndObject() error
+	StartArray() error
+	EndArray() error
+	String(value string) error
+	// Number is called with the exact literal of the number. Unlike Parse,
+	// ParseEvents does not reject numbers out of the float64 range
+	Number(value JsonNumber) error
+	Bool(value bool) error
+	Null() error
+}
+
+// SkipSubtree can be returned from StartObject or StartArray to skip the rest of the object
+// or array, including its EndObject or EndArray event, and from Key to skip the value of the member.
+// The skipped part of the input is still checked for errors
+var SkipSubtree = errors.New("skip this subtree")
+
+// BaseHandler implements Handler by ignoring every event.
+// It is meant to be embedded by handlers that only care about some of them
+type BaseHandler struct{}
+
+func (BaseHandler) StartObject() error      { return nil }
+func (BaseHandler) Key(string) error        { return nil }
+func (BaseHandler) EndObject() error        { return nil }
+func (BaseHandler) StartArray() error       { return nil }
+func (BaseHandler) EndArray() error         { return nil }
+func (BaseHandler) String(string) error     { return nil }
+func (BaseHandler) Number(JsonNumber) error { return nil }
+func (BaseHandler) Bool(bool) error         { return nil }
+func (BaseHandler) Null() error             { return nil }
+
+// ParseEvents parses the input and reports its parts to the handler as they are found,
+// without building a JsonValue. The handler may have received the events of the input
+// before an error. Unlike Parse, which checks every token before their order,
+// it reports the error that comes first in the input
+func ParseEvents(input string, h Handler) error {
+	return ParseEventsWithOptions(input, h, ParseOptions{})
+}
+
+// ParseEventsWithOptions works like ParseEvents, but lets the caller
+// configure how the input is interpreted
+func ParseEventsWithOptions(input string, h Handler, opts ParseOptions) error {
+	return ParseEventsReader(strings.NewReader(input), h, opts)
+}
+
+// ParseEventsReader works like ParseEventsWithOptions, but reads the input from r.
+// Only the token being parsed and the nesting of the objects and arrays around it
+// are kept in memory, so the input can be larger than the memory available
+func ParseEventsReader(r io.Reader, h Handler, opts ParseOptions) error {
+	tokenizer := NewTokenizerReader(r)
+	tokenizer.SetOptions(opts)
+	p := &eventParser{tokenizer: tokenizer, handler: h, recognizer: newRecognizer()}
+	return p.run()
+}
+
+type eventParser struct {
+	tokenizer  *Tokenizer
+	handler    Handler
+	recognizer *recognizer
+
+	// objects tells for every object and array around the current token if it is an object
+	objects   []bool
+	expectKey bool
+	// skipDepth is the number of objects and arrays open in the part being skipped
+	skipDepth int
+	// skipValue is set when the next value is to be skipped
+	skipValue bool
+}
+
+func (p *eventParser) run() error {
+	stream := p.tokenizer.stream
+	for {
+		lexemes, size, err := p.tokenizer.lexNext(0)
+		if err == io.EOF {
+			end := token{tokenType: ltEnd}
+			if !p.recognizer.accepts(ltEnd) {
+				return stream.locate(p.recognizer.unexpectedToken(end).locate(""))
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		for _, lexeme := range lexemes {
+			if !p.recognizer.accepts(lexeme.tokenType) {
+				input := string(stream.buf[:size])
+				return stream.locate(p.recognizer.unexpectedToken(lexeme).locate(input))
+			}
+			_, _ = p.recognizer.feed(lexeme)
+		}
+
+		stream.consume(size)
+		if err := p.emit(lexemes); err != nil {
+			return err
+		}
+	}
+}
+
+// emit reports the token made up of the terminals to the handler
+func (p *eventParser) emit(lexemes []token) error {
+	h := p.handler
+	tokenType := lexemes[0].tokenType
+
+	switch tokenType {
+	case ltComma:
+		p.expectKey = p.objects[len(p.objects)-1]
+		return nil
+	case ltColon:
+		return nil
+	case ltObjectEnd, ltArrayEnd:
+		p.objects = p.objects[:len(p.objects)-1]
+		if p.skipDepth > 0 {
+			p.skipDepth--
+			return nil
+		}
+		if tokenType == ltObjectEnd {
+			return p.handle(h.EndObject(), nil)
+		}
+		return p.handle(h.EndArray(), nil)
+	}
+
+	if tokenType == ltString && p.expectKey {
+		p.expectKey = false
+		if p.skipDepth > 0 {
+			return nil
+		}
+		return p.handle(h.Key(lexemes[0].value.(string)), func() { p.skipValue = true })
+	}
+
+	// the token starts a value
+	skipped := p.skipDepth > 0 || p.skipValue
+	p.skipValue = false
+
+	switch tokenType {
+	case ltObjectStart, ltArrayStart:
+		isObject := tokenType == ltObjectStart
+		p.objects = append(p.objects, isObject)
+		p.expectKey = isObject
+		if skipped {
+			p.skipDepth++
+			return nil
+		}
+		if isObject {
+			return p.handle(h.StartObject(), func() { p.skipDepth = 1 })
+		}
+		return p.handle(h.StartArray(), func() { p.skipDepth = 1 })
+	}
+
+	if skipped {
+		return nil
+	}
+	switch tokenType {
+	case ltString:
+		return p.handle(h.String(lexemes[0].value.(string)), nil)
+	case ltBoolean:
+		return p.handle(h.Bool(lexemes[0].value == "true"), nil)
+	case ltNull:
+		return p.handle(h.Null(), nil)
+	default:
+		return p.handle(h.Number(numberLiteral(lexemes)), nil)
+	}
+}
+
+// handle turns SkipSubtree into the skip, the rest of the errors abort the parsing
+func (p *eventParser) handle(err error, skip func()) error {
+	if err != SkipSubtree {
+		return err
+	}
+	if skip != nil {
+		skip()
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package gojson
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// recorder records the events it receives, returning the configured errors for some of them
+type recorder struct {
+	events []string
+	errors map[string]error
+}
+
+func (r *recorder) record(event string) error {
+	r.events = append(r.events, event)
+	return r.errors[event]
+}
+
+func (r *recorder) StartObject() error        { return r.record("{") }
+func (r *recorder) Key(key string) error      { return r.record("key " + key) }
+func (r *recorder) EndObject() error          { return r.record("}") }
+func (r *recorder) StartArray() error         { return r.record("[") }
+func (r *recorder) EndArray() error           { return r.record("]") }
+func (r *recorder) String(value string) error { return r.record("string " + value) }
+func (r *recorder) Number(n JsonNumber) error { return r.record("number " + n.String()) }
+func (r *recorder) Bool(value bool) error     { return r.record(fmt.Sprintf("bool %t", value)) }
+func (r *recorder) Null() error               { return r.record("null") }
+
+func TestParseEvents(t *testing.T) {
+	input := `{"a": [1, "b", {}], "c": {"d": true, "e": null}, "f": -1.5e999}`
+
+	t.Run("every event is reported", func(t *testing.T) {
+		var r recorder
+		if err := ParseEvents(input, &r); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		expected := []string{
+			"{", "key a", "[", "number 1", "string b", "{", "}", "]",
+			"key c", "{", "key d", "bool true", "key e", "null", "}",
+			"key f", "number -1.5e999", "}",
+		}
+		if !reflect.DeepEqual(r.events, expected) {
+			t.Fatalf("expected: %v, got: %v", expected, r.events)
+		}
+	})
+
+	t.Run("subtrees can be skipped", func(t *testing.T) {
+		r := recorder{errors: map[string]error{"key a": SkipSubtree, "{": SkipSubtree}}
+		if err := ParseEvents(`[{"x": 1}, 2, {"y": 3}]`, &r); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		expected := []string{"[", "{", "number 2", "{", "]"}
+		if !reflect.DeepEqual(r.events, expected) {
+			t.Fatalf("expected: %v, got: %v", expected, r.events)
+		}
+
+		r = recorder{errors: map[string]error{"key a": SkipSubtree}}
+		if err := ParseEvents(input, &r); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		expected = []string{
+			"{", "key a",
+			"key c", "{", "key d", "bool true", "key e", "null", "}",
+			"key f", "number -1.5e999", "}",
+		}
+		if !reflect.DeepEqual(r.events, expected) {
+			t.Fatalf("expected: %v, got: %v", expected, r.events)
+		}
+	})
+
+	t.Run("the handler can abort", func(t *testing.T) {
+		abort := errors.New("found it")
+		r := recorder{errors: map[string]error{"key d": abort}}
+		if err := ParseEvents(input, &r); err != abort {
+			t.Fatalf("expected: %v, got: %v", abort, err)
+		}
+		if last := r.events[len(r.events)-1]; last != "key d" {
+			t.Fatalf("expected no events after the abort, got: %v", r.events)
+		}
+	})
+
+	t.Run("base handler", func(t *testing.T) {
+		count := &counter{}
+		if err := ParseEventsReader(iotest.OneByteReader(strings.NewReader(input)), count, ParseOptions{}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if count.numbers != 2 {
+			t.Fatalf("expected 2 numbers, got: %d", count.numbers)
+		}
+	})
+}
+
+type counter struct {
+	BaseHandler
+	numbers int
+}
+
+func (c *counter) Number(JsonNumber) error {
+	c.numbers++
+	return nil
+}
+
+func TestParseEventsErrors(t *testing.T) {
+	var data = []string{
+		``,
+		`[1`,
+		`{"a" 1}`,
+		`[1, 2,]`,
+		"[1,\n nul]",
+		`1 2`,
+		`{"a": [{"b": false}`,
+		byteOrderMark + `1`,
+	}
+
+	for _, input := range data {
+		t.Run(fmt.Sprintf("events(%q)", input), func(t *testing.T) {
+			_, expected := Parse(input)
+			err := ParseEventsReader(iotest.OneByteReader(strings.NewReader(input)), BaseHandler{}, ParseOptions{})
+			if err == nil || err.Error() != expected.Error() {
+				t.Fatalf("expected: %v, got: %v", expected, err)
+			}
+		})
+	}
+}
+
+// ParseEvents accepts and rejects the same input as Parse
+func FuzzParseEvents(f *testing.F) {
+	var seeds = []string{
+		`{"a": [1, "b", {}], "c": {"d": true}}`,
+		`[1, 2,]`,
+		`{"a" 1}`,
+	}
+	for _, seed := range seeds {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, input string) {
+		_, expected := Parse(input)
+		err := ParseEvents(input, &recorder{})
+		if expected != nil && strings.HasPrefix(expected.Error(), "number out of range") {
+			return
+		}
+		if (err == nil) != (expected == nil) {
+			t.Fatalf("expected: %v, got: %v", expected, err)
+		}
+	})
+}
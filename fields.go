@@ -0,0 +1,128 @@
+package gojson
+
+import (
+	"reflect"
+	"strings"
+)
+
+// structField is a field of a struct as it appears in json
+type structField struct {
+	name string
+	// index is the index sequence of the field for reflect.Value.FieldByIndex
+	index []int
+	// omitEmpty leaves the field out of the output of Marshal when it has its empty value
+	omitEmpty bool
+	// asString writes numbers and booleans as json strings holding their literal, and reads them back
+	asString bool
+	// extras marks the map[string]JsonValue field that holds the members without a field of their own,
+	// see UnknownFieldCollect
+	extras bool
+}
+
+var extrasType = reflect.TypeOf(map[string]JsonValue{})
+
+// structFields returns the fields of the struct type that appear in json, in the order they are declared.
+// The name of a field is taken from its gojson tag, its json tag or the name of the field, in that order,
+// and the tag "-" leaves the field out. The tags follow the format of encoding/json: `json:"name,omitempty,string"`.
+// The fields of embedded structs without a name in their tag are promoted, as if they belonged to the outer struct.
+// When several fields have the same name, the least nested one wins, and the first one declared between equals.
+// A field of type map[string]JsonValue with the extras option, e.g. `gojson:",extras"`, has no name of its own
+func structFields(t reflect.Type) []structField {
+	var fields []structField
+	collectFields(t, nil, &fields)
+
+	depth := map[string]int{}
+	for _, field := range fields {
+		if field.extras {
+			continue
+		}
+		if d, ok := depth[field.name]; !ok || len(field.index) < d {
+			depth[field.name] = len(field.index)
+		}
+	}
+
+	visible := fields[:0]
+	taken := map[string]bool{}
+	for _, field := range fields {
+		if field.extras {
+			visible = append(visible, field)
+			continue
+		}
+		if len(field.index) == depth[field.name] && !taken[field.name] {
+			taken[field.name] = true
+			visible = append(visible, field)
+		}
+	}
+	return visible
+}
+
+func collectFields(t reflect.Type, index []int, fields *[]structField) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag, tagged := f.Tag.Lookup("gojson")
+		if !tagged {
+			tag, tagged = f.Tag.Lookup("json")
+		}
+		if tag == "-" {
+			continue
+		}
+
+		name, options, _ := strings.Cut(tag, ",")
+		fieldIndex := append(append([]int{}, index...), i)
+		if f.Anonymous && f.Type.Kind() == reflect.Struct && name == "" {
+			collectFields(f.Type, fieldIndex, fields)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+		field := structField{name: name, index: fieldIndex}
+		for _, option := range strings.Split(options, ",") {
+			switch option {
+			case "omitempty":
+				field.omitEmpty = true
+			case "string":
+				field.asString = true
+			case "extras":
+				field.extras = f.Type == extrasType
+			}
+		}
+		*fields = append(*fields, field)
+	}
+}
+
+// quotable checks if the values of the type are written as strings by the string tag option
+func quotable(t reflect.Type) bool {
+	if isBigNumber(t) {
+		return true
+	}
+	_, isNumber := numbers[t.Kind()]
+	return isNumber || t.Kind() == reflect.Bool
+}
+
+// isEmptyValue checks if the value is left out by the omitempty tag option
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Pointer, reflect.Interface:
+		return v.IsNil()
+	}
+	if class, ok := numbers[v.Kind()]; ok {
+		switch class {
+		case signedNumber:
+			return v.Int() == 0
+		case unsignedNumber:
+			return v.Uint() == 0
+		default:
+			return v.Float() == 0
+		}
+	}
+	return false
+}
@@ -0,0 +1,298 @@
+package gojson
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// FormatOptions configures the output of Format.
+// The zero value indents with two spaces and keeps the keys in the order they are in
+type FormatOptions struct {
+	// Indent is the number of spaces every level of nesting is indented with, 2 if not set
+	Indent int
+	// UseTabs indents with a tab per level of nesting instead of spaces
+	UseTabs bool
+	// Compact writes the whole text without any whitespace, which minifies it
+	Compact bool
+	// SortKeys writes the members of objects sorted by their keys.
+	// The keys of a JsonValue are always sorted, as maps have no order
+	SortKeys bool
+	// MaxInlineWidth, if set, keeps the objects and arrays on a single line
+	// when they take at most this many bytes written that way, e.g. [1, 2, 3]
+	MaxInlineWidth int
+	// TrailingNewline ends the output with a new line
+	TrailingNewline bool
+	// ASCIIOnly writes the characters outside of ASCII as \uXXXX escapes
+	ASCIIOnly bool
+	// Parse configures how the input of Format is parsed
+	Parse ParseOptions
+}
+
+// Format parses the input and writes it back as formatted json text.
+// Numbers are kept exactly as they are written in the input,
+// and the errors are reported the way ParseEvents reports them
+func Format(input string, opts FormatOptions) (string, error) {
+	b := &formatBuilder{}
+	if err := ParseEventsWithOptions(input, b, opts.Parse); err != nil {
+		return "", err
+	}
+	f := formatter{opts: opts}
+	return string(f.format(b.root)), nil
+}
+
+// Format writes the value as formatted json text
+func (jv JsonValue) Format(opts FormatOptions) (string, error) {
+	root, err := formatNodeOf(jv)
+	if err != nil {
+		return "", err
+	}
+	f := formatter{opts: opts}
+	return string(f.format(root)), nil
+}
+
+// formatNode is a json value with the members of its objects in order
+type formatNode struct {
+	kind JsonValueType
+	// text is the decoded string of strings and the literal of the rest of the scalars
+	text     string
+	keys     []string
+	children []*formatNode
+}
+
+func (n *formatNode) isContainer() bool {
+	return n.kind == OBJECT || n.kind == ARRAY
+}
+
+func (n *formatNode) brackets() (byte, byte) {
+	if n.kind == OBJECT {
+		return '{', '}'
+	}
+	return '[', ']'
+}
+
+// formatNodeOf converts the value, sorting the keys of its objects
+func formatNodeOf(jv JsonValue) (*formatNode, error) {
+	switch jv.ValueType {
+	case STRING:
+		return &formatNode{kind: STRING, text: jv.Value.(string)}, nil
+	case NUMBER:
+		// the same literal as the one of the input formatted directly
+		literal, err := appendNumber(nil, jv.Value)
+		if err != nil {
+			return nil, err
+		}
+		return &formatNode{kind: NUMBER, text: string(literal)}, nil
+	case BOOL, NULL:
+		literal, err := appendValue(nil, jv)
+		if err != nil {
+			return nil, err
+		}
+		return &formatNode{kind: jv.ValueType, text: string(literal)}, nil
+	case OBJECT:
+		members := jv.Value.(map[string]JsonValue)
+		node := &formatNode{kind: OBJECT, keys: make([]string, 0, len(members))}
+		for key := range members {
+			node.keys = append(node.keys, key)
+		}
+		sort.Strings(node.keys)
+		for _, key := range node.keys {
+			child, err := formatNodeOf(members[key])
+			if err != nil {
+				return nil, err
+			}
+			node.children = append(node.children, child)
+		}
+		return node, nil
+	case ARRAY:
+		node := &formatNode{kind: ARRAY}
+		for _, element := range jv.Value.([]JsonValue) {
+			child, err := formatNodeOf(element)
+			if err != nil {
+				return nil, err
+			}
+			node.children = append(node.children, child)
+		}
+		return node, nil
+	}
+	return nil, errors.New(fmt.Sprintf("cannot serialize value of type %s", jv.ValueType))
+}
+
+// formatBuilder builds the formatNode of the input from the events of ParseEvents
+type formatBuilder struct {
+	root  *formatNode
+	stack []*formatNode
+	key   string
+}
+
+func (b *formatBuilder) add(node *formatNode) {
+	if len(b.stack) == 0 {
+		b.root = node
+	} else {
+		parent := b.stack[len(b.stack)-1]
+		if parent.kind == OBJECT {
+			parent.keys = append(parent.keys, b.key)
+		}
+		parent.children = append(parent.children, node)
+	}
+	if node.isContainer() {
+		b.stack = append(b.stack, node)
+	}
+}
+
+func (b *formatBuilder) end() error {
+	b.stack = b.stack[:len(b.stack)-1]
+	return nil
+}
+
+func (b *formatBuilder) StartObject() error {
+	b.add(&formatNode{kind: OBJECT})
+	return nil
+}
+
+func (b *formatBuilder) Key(key string) error {
+	b.key = key
+	return nil
+}
+
+func (b *formatBuilder) EndObject() error { return b.end() }
+
+func (b *formatBuilder) StartArray() error {
+	b.add(&formatNode{kind: ARRAY})
+	return nil
+}
+
+func (b *formatBuilder) EndArray() error { return b.end() }
+
+func (b *formatBuilder) String(value string) error {
+	b.add(&formatNode{kind: STRING, text: value})
+	return nil
+}
+
+func (b *formatBuilder) Number(value JsonNumber) error {
+	literal, err := appendNumber(nil, value)
+	if err != nil {
+		return err
+	}
+	b.add(&formatNode{kind: NUMBER, text: string(literal)})
+	return nil
+}
+
+func (b *formatBuilder) Bool(value bool) error {
+	text := "false"
+	if value {
+		text = "true"
+	}
+	b.add(&formatNode{kind: BOOL, text: text})
+	return nil
+}
+
+func (b *formatBuilder) Null() error {
+	b.add(&formatNode{kind: NULL, text: "null"})
+	return nil
+}
+
+type formatter struct {
+	opts   FormatOptions
+	indent string
+}
+
+func (f *formatter) format(root *formatNode) []byte {
+	switch {
+	case f.opts.UseTabs:
+		f.indent = "\t"
+	case f.opts.Indent > 0:
+		f.indent = strings.Repeat(" ", f.opts.Indent)
+	default:
+		f.indent = "  "
+	}
+
+	buf := f.write(nil, root, 0)
+	if f.opts.TrailingNewline {
+		buf = append(buf, '\n')
+	}
+	return buf
+}
+
+// order returns the indexes of the children in the order they are written
+func (f *formatter) order(n *formatNode) []int {
+	indexes := make([]int, len(n.children))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	if f.opts.SortKeys && n.kind == OBJECT {
+		sort.SliceStable(indexes, func(i, j int) bool {
+			return n.keys[indexes[i]] < n.keys[indexes[j]]
+		})
+	}
+	return indexes
+}
+
+func (f *formatter) write(buf []byte, n *formatNode, depth int) []byte {
+	if !n.isContainer() || len(n.children) == 0 || f.opts.Compact {
+		return f.writeInline(buf, n, ",", ":", -1)
+	}
+
+	if f.opts.MaxInlineWidth > 0 {
+		offset := len(buf)
+		limit := offset + f.opts.MaxInlineWidth
+		if buf = f.writeInline(buf, n, ", ", ": ", limit); len(buf) <= limit {
+			return buf
+		}
+		buf = buf[:offset]
+	}
+
+	start, end := n.brackets()
+	buf = append(buf, start)
+	for i, index := range f.order(n) {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		buf = f.newLine(buf, depth+1)
+		if n.kind == OBJECT {
+			buf = appendQuoted(buf, n.keys[index], f.opts.ASCIIOnly)
+			buf = append(buf, ':', ' ')
+		}
+		buf = f.write(buf, n.children[index], depth+1)
+	}
+	buf = f.newLine(buf, depth)
+	return append(buf, end)
+}
+
+// writeInline writes the value on a single line, using the separators between the elements
+// and after the keys. It stops as soon as the buffer gets longer than the limit, unless the limit is negative
+func (f *formatter) writeInline(buf []byte, n *formatNode, comma, colon string, limit int) []byte {
+	switch n.kind {
+	case STRING:
+		return appendQuoted(buf, n.text, f.opts.ASCIIOnly)
+	case OBJECT, ARRAY:
+	default:
+		return append(buf, n.text...)
+	}
+
+	start, end := n.brackets()
+	buf = append(buf, start)
+	for i, index := range f.order(n) {
+		if limit >= 0 && len(buf) > limit {
+			return buf
+		}
+		if i > 0 {
+			buf = append(buf, comma...)
+		}
+		if n.kind == OBJECT {
+			buf = appendQuoted(buf, n.keys[index], f.opts.ASCIIOnly)
+			buf = append(buf, colon...)
+		}
+		buf = f.writeInline(buf, n.children[index], comma, colon, limit)
+	}
+	return append(buf, end)
+}
+
+func (f *formatter) newLine(buf []byte, depth int) []byte {
+	buf = append(buf, '\n')
+	for i := 0; i < depth; i++ {
+		buf = append(buf, f.indent...)
+	}
+	return buf
+}
@@ -0,0 +1,185 @@
+package gojson
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+const formatInput = `{"name": "gojson", "tags": ["parser", "lr(1)"], "version": 1.20,
+	"nested": {"empty": {}, "list": [], "deep": [[1, 2], {"b": null, "a": true}]}}`
+
+func TestFormat(t *testing.T) {
+	var data = map[string]struct {
+		opts     FormatOptions
+		expected string
+	}{
+		`default`: {FormatOptions{}, `{
+  "name": "gojson",
+  "tags": [
+    "parser",
+    "lr(1)"
+  ],
+  "version": 1.20,
+  "nested": {
+    "empty": {},
+    "list": [],
+    "deep": [
+      [
+        1,
+        2
+      ],
+      {
+        "b": null,
+        "a": true
+      }
+    ]
+  }
+}`},
+		`compact`: {FormatOptions{Compact: true, TrailingNewline: true},
+			`{"name":"gojson","tags":["parser","lr(1)"],"version":1.20,"nested":{"empty":{},"list":[],"deep":[[1,2],{"b":null,"a":true}]}}` + "\n"},
+		`sorted keys`: {FormatOptions{Compact: true, SortKeys: true},
+			`{"name":"gojson","nested":{"deep":[[1,2],{"a":true,"b":null}],"empty":{},"list":[]},"tags":["parser","lr(1)"],"version":1.20}`},
+		`tabs and inline width`: {FormatOptions{UseTabs: true, MaxInlineWidth: 22}, "{\n" +
+			"\t\"name\": \"gojson\",\n" +
+			"\t\"tags\": [\"parser\", \"lr(1)\"],\n" +
+			"\t\"version\": 1.20,\n" +
+			"\t\"nested\": {\n" +
+			"\t\t\"empty\": {},\n" +
+			"\t\t\"list\": [],\n" +
+			"\t\t\"deep\": [\n" +
+			"\t\t\t[1, 2],\n" +
+			"\t\t\t{\"b\": null, \"a\": true}\n" +
+			"\t\t]\n" +
+			"\t}\n" +
+			"}"},
+		`indent width`: {FormatOptions{Indent: 4, MaxInlineWidth: 80}, `{
+    "name": "gojson",
+    "tags": ["parser", "lr(1)"],
+    "version": 1.20,
+    "nested": {"empty": {}, "list": [], "deep": [[1, 2], {"b": null, "a": true}]}
+}`},
+	}
+
+	for name, data := range data {
+		t.Run(name, func(t *testing.T) {
+			out, err := Format(formatInput, data.opts)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if out != data.expected {
+				t.Fatalf("expected:\n%s\ngot:\n%s", data.expected, out)
+			}
+		})
+	}
+}
+
+func TestFormatScalars(t *testing.T) {
+	var data = []struct {
+		input    string
+		opts     FormatOptions
+		expected string
+	}{
+		{` "café 😀 \/" `, FormatOptions{}, `"café 😀 /"`},
+		{`"café 😀"`, FormatOptions{ASCIIOnly: true}, `"caf\u00e9 \ud83d\ude00"`},
+		{`{"ключ": "\u0001"}`, FormatOptions{ASCIIOnly: true, Compact: true}, `{"\u043a\u043b\u044e\u0447":"\u0001"}`},
+		{`[+01.5, -00, 1e999]`, FormatOptions{Compact: true, Parse: ParseOptions{LenientNumbers: true}}, `[1.5,-0,1e999]`},
+		{`null`, FormatOptions{TrailingNewline: true}, "null\n"},
+	}
+
+	for _, data := range data {
+		t.Run(data.input, func(t *testing.T) {
+			out, err := Format(data.input, data.opts)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if out != data.expected {
+				t.Fatalf("expected: %s, got: %s", data.expected, out)
+			}
+		})
+	}
+}
+
+func TestFormatErrors(t *testing.T) {
+	_, err := Format(`{"a": [1, 2}`, FormatOptions{})
+	if err == nil || !strings.Contains(err.Error(), "at 1:12") {
+		t.Fatalf("expected an error at 1:12, got: %v", err)
+	}
+
+	_, err = JsonValue{ValueType: INVALID}.Format(FormatOptions{})
+	if err == nil {
+		t.Fatalf("expected an error for invalid values")
+	}
+}
+
+func TestJsonValueFormat(t *testing.T) {
+	json, err := ParseWithOptions(`{"z": [1.5, "é"], "a": {"n": 12345678901234567890}}`, ParseOptions{UseNumber: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	out, ferr := json.Format(FormatOptions{ASCIIOnly: true, MaxInlineWidth: 20})
+	if ferr != nil {
+		t.Fatalf("unexpected error: %v", ferr)
+	}
+	expected := `{
+  "a": {
+    "n": 12345678901234567890
+  },
+  "z": [1.5, "\u00e9"]
+}`
+	if out != expected {
+		t.Fatalf("expected:\n%s\ngot:\n%s", expected, out)
+	}
+
+	// lenient numbers come out like they do when formatting the input directly
+	input := `[0123, -007.5, +00]`
+	opts := ParseOptions{LenientNumbers: true, UseNumber: true}
+	lenient, err := ParseWithOptions(input, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out, ferr = lenient.Format(FormatOptions{Compact: true, Parse: opts})
+	direct, derr := Format(input, FormatOptions{Compact: true, Parse: opts})
+	if ferr != nil || derr != nil || out != `[123,-7.5,0]` || out != direct {
+		t.Fatalf("expected: [123,-7.5,0], got: %s and %s, %v, %v", out, direct, ferr, derr)
+	}
+}
+
+// formatting keeps the value, whichever the options are
+func FuzzFormat(f *testing.F) {
+	f.Add(formatInput, uint8(0), 0)
+	f.Add(`[1, {"a": "é"}, []]`, uint8(0xff), 10)
+
+	f.Fuzz(func(t *testing.T, input string, flags uint8, width int) {
+		expected, err := Parse(input)
+		if err != nil {
+			return
+		}
+		opts := FormatOptions{
+			Indent:          int(flags & 3),
+			UseTabs:         flags&4 != 0,
+			Compact:         flags&8 != 0,
+			SortKeys:        flags&16 != 0,
+			TrailingNewline: flags&32 != 0,
+			ASCIIOnly:       flags&64 != 0,
+			MaxInlineWidth:  width % 100,
+		}
+		if !utf8.ValidString(input) {
+			// invalid bytes are escaped as U+FFFD
+			return
+		}
+		out, ferr := Format(input, opts)
+		if ferr != nil {
+			t.Fatalf("unexpected error: %v", ferr)
+		}
+		json, perr := Parse(out)
+		if perr != nil {
+			t.Fatalf("%q is not valid json: %v", out, perr)
+		}
+		if !reflect.DeepEqual(expected, json) {
+			t.Fatalf("expected: %v, got: %v", expected, json)
+		}
+	})
+}
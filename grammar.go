@@ -33,14 +33,19 @@ const (
 	ltExponent       elementType = "e/E"
 	ltDigits         elementType = "[0-9] (digits)"
 	ltNull           elementType = "<null>"
+	ltMinus          elementType = "-"
 	ltSign           elementType = "+/-"
 	ltString         elementType = "<string_literal>"
+	ltEnd            elementType = "<end of input>"
+	/* stand-ins for malformed input, produced only while recovering from errors */
+	ltInvalid       elementType = "<invalid>"
+	ltInvalidMember elementType = "<invalid object field>"
 )
 
 type grammarRule struct {
 	lhs    string
 	rhs    [][]elementType
-	toJson func(values ...*stackElement) JsonValue
+	toJson func(opts *ParseOptions, values ...*stackElement) (JsonValue, *Error)
 }
 
 var grammar = []grammarRule{
@@ -51,47 +56,53 @@ var grammar = []grammarRule{
 		{boolean},
 		{ltString},
 		{ltNull},
-	}, func(values ...*stackElement) JsonValue {
+		{ltInvalid},
+	}, func(opts *ParseOptions, values ...*stackElement) (JsonValue, *Error) {
 		v := values[0].Value()
-		if str, ok := v.(string); ok {
+		if err, ok := v.(*Error); ok {
+			return JsonValue{
+				Value:     err,
+				ValueType: INVALID,
+			}, nil
+		} else if str, ok := v.(string); ok {
 			return JsonValue{
 				Value:     str,
 				ValueType: STRING,
-			}
+			}, nil
 		} else if v == nil {
 			return JsonValue{
 				Value:     nil,
 				ValueType: NULL,
-			}
+			}, nil
 		}
-		return values[0].asJsonValue()
+		return values[0].asJsonValue(), nil
 	}},
 	grammarRule{boolean, [][]elementType{
 		{ltBoolean},
-	}, func(values ...*stackElement) JsonValue {
+	}, func(opts *ParseOptions, values ...*stackElement) (JsonValue, *Error) {
 		b := values[0].Value().(string)
 		return JsonValue{
 			Value:     b == "true",
 			ValueType: BOOL,
-		}
+		}, nil
 	}},
 	grammarRule{object, [][]elementType{
 		{ltObjectStart, ltObjectEnd},
 		{ltObjectStart, members, ltObjectEnd},
-	}, func(values ...*stackElement) JsonValue {
+	}, func(opts *ParseOptions, values ...*stackElement) (JsonValue, *Error) {
 		// TODO: incomplete
 		if len(values) == 2 {
 			return JsonValue{
 				Value:     map[string]JsonValue{},
 				ValueType: OBJECT,
-			}
+			}, nil
 		}
-		return values[1].asJsonValue()
+		return values[1].asJsonValue(), nil
 	}},
 	grammarRule{members, [][]elementType{
 		{member},
 		{members, ltComma, member},
-	}, func(values ...*stackElement) JsonValue {
+	}, func(opts *ParseOptions, values ...*stackElement) (JsonValue, *Error) {
 		size := len(values)
 		members := map[string]JsonValue{}
 		member := values[size-1].asJsonValue().Value.(map[string]JsonValue)
@@ -107,11 +118,19 @@ var grammar = []grammarRule{
 		return JsonValue{
 			ValueType: OBJECT,
 			Value:     members,
-		}
+		}, nil
 	}},
 	grammarRule{member, [][]elementType{
 		{ltString, ltColon, value},
-	}, func(values ...*stackElement) JsonValue {
+		{ltInvalidMember},
+	}, func(opts *ParseOptions, values ...*stackElement) (JsonValue, *Error) {
+		if len(values) == 1 {
+			// a malformed field is left out of the object
+			return JsonValue{
+				ValueType: OBJECT,
+				Value:     map[string]JsonValue{},
+			}, nil
+		}
 		key := fmt.Sprintf("%s", values[0].Value())
 		valueObj := values[2].asJsonValue()
 
@@ -120,24 +139,24 @@ var grammar = []grammarRule{
 			Value: map[string]JsonValue{
 				key: valueObj,
 			},
-		}
+		}, nil
 	}},
 	grammarRule{array, [][]elementType{
 		{ltArrayStart, ltArrayEnd},
 		{ltArrayStart, elements, ltArrayEnd},
-	}, func(values ...*stackElement) JsonValue {
+	}, func(opts *ParseOptions, values ...*stackElement) (JsonValue, *Error) {
 		if len(values) == 2 {
 			return JsonValue{
 				ValueType: ARRAY,
 				Value:     []JsonValue{},
-			}
+			}, nil
 		}
-		return values[1].asJsonValue()
+		return values[1].asJsonValue(), nil
 	}},
 	grammarRule{elements, [][]elementType{
 		{element},
 		{elements, ltComma, element},
-	}, func(values ...*stackElement) JsonValue {
+	}, func(opts *ParseOptions, values ...*stackElement) (JsonValue, *Error) {
 		size := len(values)
 
 		var elements []JsonValue
@@ -151,91 +170,101 @@ var grammar = []grammarRule{
 		return JsonValue{
 			ValueType: ARRAY,
 			Value:     elements,
-		}
+		}, nil
 	}},
 	grammarRule{element, [][]elementType{
 		{value},
-	}, func(values ...*stackElement) JsonValue {
-		return values[0].asJsonValue()
+	}, func(opts *ParseOptions, values ...*stackElement) (JsonValue, *Error) {
+		return values[0].asJsonValue(), nil
 	}},
 	grammarRule{number, [][]elementType{
 		{integer, fraction, exponent},
 		{integer, fraction},
 		{integer, exponent},
 		{integer},
-	}, func(values ...*stackElement) JsonValue {
-		size := len(values)
-		var integerValue = values[0].asJsonValue().Value.(string)
-
-		var fraction string
-		if size >= 2 && strings.HasPrefix(values[1].asJsonValue().Value.(string), ".") {
-			fraction = values[1].asJsonValue().Value.(string)
-		} else {
-			fraction = ""
+	}, func(opts *ParseOptions, values ...*stackElement) (JsonValue, *Error) {
+		// integer, fraction and exponent each hold their part of the literal
+		var sb strings.Builder
+		for _, v := range values {
+			sb.WriteString(v.asJsonValue().Value.(string))
 		}
+		literal := sb.String()
 
-		var exponent string
-		if size == 2 && strings.HasPrefix(values[1].asJsonValue().Value.(string), "e") {
-			exponent = values[1].asJsonValue().Value.(string)
-		} else if size == 3 && strings.HasPrefix(values[2].asJsonValue().Value.(string), "e") {
-			exponent = values[2].asJsonValue().Value.(string)
-		} else {
-			exponent = ""
+		if opts.UseNumber {
+			return JsonValue{
+				Value:     JsonNumber(literal),
+				ValueType: NUMBER,
+			}, nil
 		}
 
-		expression := fmt.Sprintf("%s%s%s", integerValue, fraction, exponent)
-		value, err := strconv.ParseFloat(expression, 64) // TODO: potential for an error!
-
+		value, err := strconv.ParseFloat(literal, 64)
 		if err != nil {
-			fmt.Printf("%s\n", err.Error())
+			// the lexer only lets through valid literals, so the value is out of range
+			switch opts.NumberOverflow {
+			case OverflowClamp:
+				// ParseFloat has already clamped the value to ±Inf
+			case OverflowExact:
+				return JsonValue{
+					Value:     JsonNumber(literal),
+					ValueType: NUMBER,
+				}, nil
+			default:
+				return JsonValue{}, newError(values[0].Start(), fmt.Sprintf("number out of range: %s", literal))
+			}
 		}
 
 		return JsonValue{
 			Value:     value,
 			ValueType: NUMBER,
-		}
+		}, nil
 	}},
 	grammarRule{integer, [][]elementType{
 		{ltDigits},
-		{ltSign, ltDigits},
-	}, func(values ...*stackElement) JsonValue {
+		{ltMinus, ltDigits},
+	}, func(opts *ParseOptions, values ...*stackElement) (JsonValue, *Error) {
 		size := len(values)
-		digits := values[size-1]
-		var sign uint8 = '+'
+		v := values[size-1].Value().(string)
 		if size == 2 {
-			sign = values[0].Value().(uint8) // - or +
+			v = "-" + v
 		}
-		v := fmt.Sprintf("%c%s", sign, digits.Value())
 		return JsonValue{
 			Value:     v,
 			ValueType: NUMBER,
-		}
+		}, nil
 	}},
 	grammarRule{fraction, [][]elementType{
 		{ltFractionSymbol, ltDigits},
-	}, func(values ...*stackElement) JsonValue {
+	}, func(opts *ParseOptions, values ...*stackElement) (JsonValue, *Error) {
 		var fractionDigits = fmt.Sprintf(".%s", values[1].Value())
 
 		return JsonValue{
 			Value:     fractionDigits,
 			ValueType: NUMBER,
-		}
+		}, nil
 	}},
 	grammarRule{exponent, [][]elementType{
-		{ltExponent, integer},
-	}, func(values ...*stackElement) JsonValue {
-		var exponentExpr = fmt.Sprintf("e%s", values[1].asJsonValue().Value.(string))
+		{ltExponent, ltDigits},
+		{ltExponent, ltSign, ltDigits},
+	}, func(opts *ParseOptions, values ...*stackElement) (JsonValue, *Error) {
+		size := len(values)
+		var sign string
+		if size == 3 {
+			sign = fmt.Sprintf("%c", values[1].Value()) // - or +
+		}
+		var exponentExpr = fmt.Sprintf("%c%s%s", values[0].Value(), sign, values[size-1].Value())
 
 		return JsonValue{
 			Value:     exponentExpr,
 			ValueType: NUMBER,
-		}
+		}, nil
 	}},
 }
 
 type jsonElement struct {
 	value           interface{}
 	jsonElementType elementType
+	start           int
+	end             int
 }
 
 type stackElement struct {
@@ -257,6 +286,22 @@ func (se stackElement) Value() interface{} {
 	return se.rule.value
 }
 
+// Start returns the offset of the first byte the element was built from
+func (se stackElement) Start() int {
+	if se.rule == nil {
+		return se.value.start
+	}
+	return se.rule.start
+}
+
+// End returns the offset right after the last byte the element was built from
+func (se stackElement) End() int {
+	if se.rule == nil {
+		return se.value.end
+	}
+	return se.rule.end
+}
+
 func (se stackElement) asJsonValue() JsonValue {
 	return se.rule.value.(JsonValue)
 }
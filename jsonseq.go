@@ -0,0 +1,216 @@
+package gojson
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// recordSeparator starts every record of a json text sequence
+const recordSeparator = 0x1e
+
+// JSONSeqOptions configures the behaviour of JSONSeqReader.
+// The zero value skips malformed records, as RFC 7464 recommends
+type JSONSeqOptions struct {
+	// Unmarshal configures how the records are parsed and deserialized
+	Unmarshal UnmarshalOptions
+	// StopAtInvalidRecords makes the reader stop at the first record
+	// that cannot be parsed or deserialized instead of skipping it
+	StopAtInvalidRecords bool
+}
+
+// RecordError is an error found in a record of a json text sequence
+type RecordError struct {
+	// Record is the 1-based number of the record, 0 for the text before the first record
+	Record int
+	Err    error
+}
+
+func (re *RecordError) Error() string {
+	return fmt.Sprintf("record %d: %s", re.Record, re.Err)
+}
+
+func (re *RecordError) Unwrap() error {
+	return re.Err
+}
+
+// JSONSeqReader reads json text sequences as defined by RFC 7464:
+// json texts each preceded by the record separator byte 0x1E and usually followed by a new line
+type JSONSeqReader struct {
+	stream *streamBuffer
+	opts   JSONSeqOptions
+
+	record  int // the number of the last record read
+	started bool
+	errs    []error
+	err     error // the error the reader stopped at
+}
+
+// NewJSONSeqReader returns a reader of the json text sequence read from r
+func NewJSONSeqReader(r io.Reader) *JSONSeqReader {
+	return NewJSONSeqReaderWithOptions(r, JSONSeqOptions{})
+}
+
+// NewJSONSeqReaderWithOptions works like NewJSONSeqReader, but lets the caller
+// configure how the records are parsed and what happens to malformed records
+func NewJSONSeqReaderWithOptions(r io.Reader, opts JSONSeqOptions) *JSONSeqReader {
+	return &JSONSeqReader{stream: newStreamBuffer(r), opts: opts}
+}
+
+// Read parses the next record. It returns io.EOF if there are no more records.
+// Malformed records are skipped unless JSONSeqOptions.StopAtInvalidRecords is set,
+// in which case a *RecordError is returned
+func (r *JSONSeqReader) Read() (JsonValue, error) {
+	return r.next(nil)
+}
+
+// Decode reads the next record and deserializes it into the provided object,
+// which needs to be a pointer. If the value cannot be deserialized and invalid records are skipped,
+// the object may have been partially filled in by the time the next record is decoded into it
+func (r *JSONSeqReader) Decode(ptr any) error {
+	_, err := r.next(ptr)
+	return err
+}
+
+// Record returns the number of the record the last value was read from
+func (r *JSONSeqReader) Record() int {
+	return r.record
+}
+
+// Errors returns the errors of the records that have been skipped so far
+func (r *JSONSeqReader) Errors() []error {
+	return r.errs
+}
+
+func (r *JSONSeqReader) next(ptr any) (JsonValue, error) {
+	for r.err == nil {
+		end, err := r.nextRecord()
+		if err != nil {
+			r.err = err
+			break
+		}
+
+		input := string(r.stream.buf[:end])
+		if !r.started {
+			// anything before the first record separator is not part of any record
+			r.started = true
+			if strings.TrimLeft(input, " \t\r\n") != "" {
+				r.fail(&RecordError{0, r.stream.locate(newError(0, "expected record separator").locate(input))})
+			}
+			r.consume(end)
+			continue
+		}
+
+		// consecutive record separators do not make up empty records
+		if strings.TrimLeft(input, " \t\r\n") == "" {
+			r.consume(end)
+			continue
+		}
+
+		r.record++
+		json, rerr := r.parseRecord(input, ptr)
+		r.consume(end)
+		if rerr == nil {
+			return json, nil
+		}
+		r.fail(rerr)
+	}
+	return JsonValue{}, r.err
+}
+
+// nextRecord finds where the record at the start of the buffer ends:
+// at the next record separator or at the end of the stream
+func (r *JSONSeqReader) nextRecord() (int, error) {
+	stream := r.stream
+	i := 0
+	for {
+		if end := bytes.IndexByte(stream.buf[i:], recordSeparator); end != -1 {
+			return i + end, nil
+		}
+		i = len(stream.buf)
+		if !stream.fill() {
+			if stream.err != nil {
+				return 0, stream.err
+			}
+			if i == 0 && r.started {
+				return 0, io.EOF
+			}
+			return i, nil
+		}
+	}
+}
+
+// consume drops the record and the record separator after it
+func (r *JSONSeqReader) consume(end int) {
+	if end < len(r.stream.buf) {
+		end++
+	}
+	r.stream.consume(end)
+}
+
+// fail stops the reader at the error, or keeps it if invalid records are skipped
+func (r *JSONSeqReader) fail(err error) {
+	if r.opts.StopAtInvalidRecords {
+		r.err = err
+	} else {
+		r.errs = append(r.errs, err)
+	}
+}
+
+func (r *JSONSeqReader) parseRecord(input string, ptr any) (JsonValue, *RecordError) {
+	json, err := ParseWithOptions(input, r.opts.Unmarshal.Parse)
+	if err != nil {
+		return JsonValue{}, &RecordError{r.record, r.stream.locate(err)}
+	}
+
+	// a number or a literal cut short can still be valid json, e.g. 12 out of 123 or
+	// null out of nullable, that is why RFC 7464 asks for whitespace after them
+	if json.ValueType == NUMBER || json.ValueType == BOOL || json.ValueType == NULL {
+		if last := input[len(input)-1]; !isWhitespace(last) {
+			err := newError(len(input), fmt.Sprintf("possibly truncated record: %s is not followed by whitespace", strings.TrimSpace(input)))
+			return JsonValue{}, &RecordError{r.record, r.stream.locate(err.locate(input))}
+		}
+	}
+
+	if ptr != nil {
+		if err := json.UnmarshalWithOptions(ptr, r.opts.Unmarshal); err != nil {
+			return JsonValue{}, &RecordError{r.record, err}
+		}
+	}
+	return json, nil
+}
+
+// JSONSeqWriter writes json text sequences as defined by RFC 7464
+type JSONSeqWriter struct {
+	writer io.Writer
+	buf    []byte
+}
+
+// NewJSONSeqWriter returns a writer of json text sequences writing to w
+func NewJSONSeqWriter(w io.Writer) *JSONSeqWriter {
+	return &JSONSeqWriter{writer: w}
+}
+
+// WriteValue writes the value in its compact form as a record:
+// preceded by the record separator and followed by a new line
+func (w *JSONSeqWriter) WriteValue(jv JsonValue) error {
+	buf, err := appendValue(append(w.buf[:0], recordSeparator), jv)
+	if err != nil {
+		return err
+	}
+	w.buf = append(buf, '\n')
+	_, err = w.writer.Write(w.buf)
+	return err
+}
+
+// Encode serializes the object with Marshal and writes it as a record
+func (w *JSONSeqWriter) Encode(v any) error {
+	buf, err := marshal(append(w.buf[:0], recordSeparator), v)
+	if err != nil {
+		return err
+	}
+	w.buf = append(buf, '\n')
+	_, err = w.writer.Write(w.buf)
+	return err
+}
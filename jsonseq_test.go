@@ -0,0 +1,142 @@
+package gojson
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func readSeq(r *JSONSeqReader) ([]JsonValue, error) {
+	var values []JsonValue
+	for {
+		json, err := r.Read()
+		if err != nil {
+			return values, err
+		}
+		values = append(values, json)
+	}
+}
+
+func TestJSONSeqReader(t *testing.T) {
+	input := "\x1e{\"a\": [1]}\n\x1e\x1e\"x\"\n\x1etrue\n\x1e[null]"
+	expected := []JsonValue{
+		{map[string]JsonValue{"a": {[]JsonValue{{float64(1), NUMBER}}, ARRAY}}, OBJECT},
+		{"x", STRING},
+		{true, BOOL},
+		{[]JsonValue{{nil, NULL}}, ARRAY},
+	}
+
+	r := NewJSONSeqReader(iotest.OneByteReader(strings.NewReader(input)))
+	values, err := readSeq(r)
+	if err != io.EOF {
+		t.Fatalf("expected io.EOF, got: %v", err)
+	}
+	if !reflect.DeepEqual(values, expected) {
+		t.Fatalf("expected: %v, got: %v", expected, values)
+	}
+	if r.Record() != 4 || len(r.Errors()) != 0 {
+		t.Fatalf("expected 4 records without errors, got: %d, %v", r.Record(), r.Errors())
+	}
+}
+
+func TestJSONSeqReaderRecovery(t *testing.T) {
+	// the second record is malformed, the third and the last ones are truncated
+	input := "\x1e{\"a\": 1}\n\x1e{\"a\" 2}\n\x1e12\x1e[3]\n\x1enul"
+
+	t.Run("malformed records are skipped", func(t *testing.T) {
+		r := NewJSONSeqReader(strings.NewReader(input))
+		values, err := readSeq(r)
+		if err != io.EOF {
+			t.Fatalf("expected io.EOF, got: %v", err)
+		}
+
+		expected := []JsonValue{
+			{map[string]JsonValue{"a": {float64(1), NUMBER}}, OBJECT},
+			{[]JsonValue{{float64(3), NUMBER}}, ARRAY},
+		}
+		if !reflect.DeepEqual(values, expected) {
+			t.Fatalf("expected: %v, got: %v", expected, values)
+		}
+
+		var messages []string
+		for _, err := range r.Errors() {
+			messages = append(messages, err.Error())
+		}
+		expectedMessages := []string{
+			"record 2: expected ':' after object key, found number at 2:7",
+			"record 3: possibly truncated record: 12 is not followed by whitespace at 3:4",
+			"record 5: unrecognized token at 4:2",
+		}
+		if !reflect.DeepEqual(messages, expectedMessages) {
+			t.Fatalf("expected: %v, got: %v", expectedMessages, messages)
+		}
+	})
+
+	t.Run("the reader can stop at malformed records", func(t *testing.T) {
+		r := NewJSONSeqReaderWithOptions(strings.NewReader(input), JSONSeqOptions{StopAtInvalidRecords: true})
+		values, err := readSeq(r)
+		if len(values) != 1 {
+			t.Fatalf("expected 1 value, got: %v", values)
+		}
+
+		var recordErr *RecordError
+		var syntaxErr *Error
+		if !errors.As(err, &recordErr) || recordErr.Record != 2 || !errors.As(err, &syntaxErr) {
+			t.Fatalf("expected a syntax error in record 2, got: %v", err)
+		}
+		if syntaxErr.Offset != 16 || syntaxErr.Pos != 16 {
+			t.Fatalf("expected the error at offset 16, got: %d", syntaxErr.Offset)
+		}
+	})
+
+	t.Run("text before the first record", func(t *testing.T) {
+		r := NewJSONSeqReader(strings.NewReader("junk\x1e1\n"))
+		values, err := readSeq(r)
+		if err != io.EOF || len(values) != 1 {
+			t.Fatalf("expected 1 value, got: %v, %v", values, err)
+		}
+		if errs := r.Errors(); len(errs) != 1 || errs[0].Error() != "record 0: expected record separator at 1:1" {
+			t.Fatalf("unexpected errors: %v", errs)
+		}
+	})
+}
+
+func TestJSONSeqWriter(t *testing.T) {
+	var out bytes.Buffer
+	w := NewJSONSeqWriter(&out)
+	for _, value := range []JsonValue{{float64(1), NUMBER}, {[]JsonValue{{"a", STRING}}, ARRAY}} {
+		if err := w.WriteValue(value); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	expected := "\x1e1\n\x1e[\"a\"]\n"
+	if out.String() != expected {
+		t.Fatalf("expected: %q, got: %q", expected, out.String())
+	}
+
+	// the top-level number is not mistaken for a truncated one
+	values, err := readSeq(NewJSONSeqReaderWithOptions(&out, JSONSeqOptions{StopAtInvalidRecords: true}))
+	if err != io.EOF || len(values) != 2 {
+		t.Fatalf("expected 2 values, got: %v, %v", values, err)
+	}
+}
+
+func TestJSONSeqWriterEncode(t *testing.T) {
+	var out bytes.Buffer
+	w := NewJSONSeqWriter(&out)
+	for _, value := range []any{map[string]bool{"ok": true}, 2.5} {
+		if err := w.Encode(value); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	expected := "\x1e{\"ok\":true}\n\x1e2.5\n"
+	if out.String() != expected {
+		t.Fatalf("expected: %q, got: %q", expected, out.String())
+	}
+}
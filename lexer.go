@@ -1,12 +1,18 @@
 package gojson
 
 import (
+	"fmt"
 	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
 )
 
 type token struct {
 	value     any
 	tokenType elementType
+	start     int // offset of the first byte of the token
+	end       int // offset right after the last byte of the token
 }
 
 var specialSymbols = map[uint8]elementType{
@@ -16,117 +22,405 @@ var specialSymbols = map[uint8]elementType{
 	']': ltArrayEnd,
 	',': ltComma,
 	':': ltColon,
-	'.': ltFractionSymbol,
 }
 
 func isWhitespace(ch uint8) bool {
-	return ch == ' ' || ch == '\t' || ch == '\n'
+	return ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r'
 }
 
 func isDigit(ch uint8) bool {
 	return ch >= '0' && ch <= '9'
 }
 
-func lex(input string) ([]token, *Error) {
+// text is the input of the lexer: whole documents are lexed from strings,
+// while streams are lexed from their buffer, without copying it into a string
+type text interface {
+	string | []byte
+}
+
+// byteOrderMark is the UTF-8 encoding of U+FEFF
+const byteOrderMark = "\xef\xbb\xbf"
+
+func lex(input string, opts ParseOptions) ([]token, *Error) {
+	tokens, errs := scan(input, opts, false)
+	if len(errs) != 0 {
+		return nil, errs[0]
+	}
+	return tokens, nil
+}
+
+// scan lexes the whole input. If recover is set, it does not stop at the first error,
+// but replaces the malformed part of the input with an invalid token and carries on
+func scan(input string, opts ParseOptions, recover bool) ([]token, []*Error) {
 	var tokens []token
-	for i := 0; i < len(input); {
-		ch := input[i]
+	var errs []*Error
 
-		if _, ok := specialSymbols[ch]; ok {
-			tokens = append(tokens, token{
-				tokenType: specialSymbols[ch],
-			})
-			i++
-		} else if ch == '"' {
-			token, offset, err := lexString(input, i)
-			if err != nil {
-				return nil, err
-			}
-			tokens = append(tokens, token)
-			i += offset
-		} else if ch == 't' {
-			if "true" == input[i:i+4] {
-				tokens = append(tokens, token{
-					value:     "true",
-					tokenType: ltBoolean,
-				})
-				i += 4
-			} else {
-				return nil, newError(i, "unrecognized token")
+	i := 0
+	if strings.HasPrefix(input, byteOrderMark) {
+		if !opts.AllowBOM {
+			errs = append(errs, newError(0, "input starts with a byte order mark"))
+			if !recover {
+				return nil, errs
 			}
-		} else if ch == 'f' {
-			if "false" == input[i:i+5] {
-				tokens = append(tokens, token{
-					value:     "false",
-					tokenType: ltBoolean,
-				})
-				i += 5
-			} else {
-				return nil, newError(i, "unrecognized token")
-			}
-		} else if ch == 'n' {
-			if "null" == input[i:i+4] {
-				tokens = append(tokens, token{
-					tokenType: ltNull,
-				})
-				i += 4
-			} else {
-				return nil, newError(i, "unrecognized token")
+		}
+		i = len(byteOrderMark)
+	}
+
+	for i < len(input) {
+		if isWhitespace(input[i]) {
+			i++
+			continue
+		}
+
+		lexed, offset, err := lexToken(tokens, input, i, opts)
+		if err != nil {
+			errs = append(errs, err)
+			if !recover {
+				return nil, errs
 			}
-		} else if isWhitespace(ch) {
-			for i < len(input) && isWhitespace(input[i]) {
-				i++
+			end := skipInvalid(input, i)
+			lexed = append(tokens, token{value: err, tokenType: ltInvalid, start: i, end: end})
+			offset = end - i
+		}
+		tokens = lexed
+		i += offset
+	}
+	return tokens, errs
+}
+
+// lexToken lexes the token starting at i and appends it to tokens. Numbers consist of multiple tokens,
+// that is why the slice is returned alongside the number of bytes consumed.
+// On error, tokens is returned as it was
+func lexToken[T text](tokens []token, input T, i int, opts ParseOptions) ([]token, int, *Error) {
+	ch := input[i]
+
+	if tokenType, ok := specialSymbols[ch]; ok {
+		return append(tokens, token{tokenType: tokenType, start: i, end: i + 1}), 1, nil
+	} else if ch == '"' {
+		str, offset, err := lexString(input, i, opts)
+		if err != nil {
+			return tokens, -1, err
+		}
+		return append(tokens, str), offset, nil
+	} else if ch == 't' || ch == 'f' || ch == 'n' {
+		keyword, offset, err := lexKeyword(input, i)
+		if err != nil {
+			return tokens, -1, err
+		}
+		return append(tokens, keyword), offset, nil
+	} else if ch == '-' || ch == '+' || isDigit(ch) {
+		lexed, offset, err := lexNumber(tokens, input, i, opts)
+		if err != nil {
+			return tokens, -1, err
+		}
+		return lexed, offset, nil
+	} else if ch == '.' {
+		return tokens, -1, newError(i, "numbers cannot start with a decimal point")
+	}
+	return tokens, -1, newError(i, "unrecognized token")
+}
+
+// skipInvalid finds where the malformed token starting at i ends:
+// at the closing quotes for strings, at the next delimiter for everything else
+func skipInvalid(input string, i int) int {
+	if input[i] == '"' {
+		for j := i + 1; j < len(input); j++ {
+			if input[j] == '\\' {
+				j++
+			} else if input[j] == '"' {
+				return j + 1
+			} else if input[j] == '\n' {
+				return j
 			}
-		} else if ch == 'e' || ch == 'E' {
-			tokens = append(tokens, token{
-				tokenType: ltExponent,
-			})
-			i++
-		} else if ch == '+' || ch == '-' {
-			tokens = append(tokens, token{
-				value:     ch,
-				tokenType: ltSign,
-			})
+		}
+		return len(input)
+	}
+
+	j := i + 1
+	for j < len(input) {
+		if _, ok := specialSymbols[input[j]]; ok || isWhitespace(input[j]) || input[j] == '"' {
+			break
+		}
+		j++
+	}
+	return j
+}
+
+// keywords maps the first letter of the literal names json supports to their tokens
+var keywords = map[uint8]struct {
+	name  string
+	token token
+}{
+	't': {"true", token{value: "true", tokenType: ltBoolean}},
+	'f': {"false", token{value: "false", tokenType: ltBoolean}},
+	'n': {"null", token{tokenType: ltNull}},
+}
+
+func lexKeyword[T text](input T, i int) (token, int, *Error) {
+	keyword, ok := keywords[input[i]]
+	if !ok || !hasPrefixAt(input, i, keyword.name) {
+		return token{}, -1, newError(i, "unrecognized token")
+	}
+	token := keyword.token
+	token.start, token.end = i, i+len(keyword.name)
+	return token, len(keyword.name), nil
+}
+
+// hasPrefixAt checks if the input continues with the prefix at i
+func hasPrefixAt[T text](input T, i int, prefix string) bool {
+	if len(input)-i < len(prefix) {
+		return false
+	}
+	for j := 0; j < len(prefix); j++ {
+		if input[i+j] != prefix[j] {
+			return false
+		}
+	}
+	return true
+}
+
+// lexNumber lexes a number as defined by RFC 8259:
+//
+//	[ minus ] int [ frac ] [ exp ]
+//
+// into the tokens the number rules of the grammar are built from, appending them to tokens.
+// The lenient mode additionally accepts a leading plus sign and leading zeros
+func lexNumber[T text](tokens []token, input T, i int, opts ParseOptions) ([]token, int, *Error) {
+	start := i
+
+	if input[i] == '-' {
+		tokens = append(tokens, token{value: "-", tokenType: ltMinus, start: i, end: i + 1})
+		i++
+	} else if input[i] == '+' {
+		if !opts.LenientNumbers {
+			return nil, -1, newError(i, "numbers cannot start with a plus sign")
+		}
+		i++ // the plus sign does not change the value
+	}
+
+	if i >= len(input) || !isDigit(input[i]) {
+		return nil, -1, newError(i, "expected digit in number")
+	}
+
+	integerDigits, offset := lexDigits(input, i)
+	if offset > 1 && input[i] == '0' && !opts.LenientNumbers {
+		return nil, -1, newError(i, "numbers cannot have leading zeros")
+	}
+	tokens = append(tokens, integerDigits)
+	i += offset
+
+	if i < len(input) && input[i] == '.' {
+		tokens = append(tokens, token{value: ".", tokenType: ltFractionSymbol, start: i, end: i + 1})
+		i++
+		if i >= len(input) || !isDigit(input[i]) {
+			return nil, -1, newError(i, "expected digit after decimal point")
+		}
+		fractionDigits, offset := lexDigits(input, i)
+		tokens = append(tokens, fractionDigits)
+		i += offset
+	}
+
+	if i < len(input) && (input[i] == 'e' || input[i] == 'E') {
+		tokens = append(tokens, token{value: input[i], tokenType: ltExponent, start: i, end: i + 1})
+		i++
+		if i < len(input) && (input[i] == '+' || input[i] == '-') {
+			tokens = append(tokens, token{value: input[i], tokenType: ltSign, start: i, end: i + 1})
 			i++
-		} else if isDigit(ch) {
-			token, offset := lexDigits(input, i)
-			tokens = append(tokens, token)
-			i += offset
-		} else {
-			return nil, newError(i, "unrecognized token")
 		}
+		if i >= len(input) || !isDigit(input[i]) {
+			return nil, -1, newError(i, "expected digit in exponent")
+		}
+		exponentDigits, offset := lexDigits(input, i)
+		tokens = append(tokens, exponentDigits)
+		i += offset
 	}
-	return tokens, nil
+
+	return tokens, i - start, nil
 }
 
-func lexDigits(input string, i int) (token, int) {
-	var sb strings.Builder
+func lexDigits[T text](input T, i int) (token, int) {
+	start := i
 	for i < len(input) && isDigit(input[i]) {
-		sb.WriteByte(input[i])
 		i++
 	}
 
 	return token{
 		tokenType: ltDigits,
-		value:     sb.String(),
-	}, sb.Len()
+		value:     string(input[start:i]),
+		start:     start,
+		end:       i,
+	}, i - start
 }
 
-func lexString(input string, i int) (token, int, *Error) {
+// escapeSequences maps the character following a backslash
+// to the byte it represents in the decoded string
+var escapeSequences = map[uint8]uint8{
+	'"':  '"',
+	'\\': '\\',
+	'/':  '/',
+	'b':  '\b',
+	'f':  '\f',
+	'n':  '\n',
+	'r':  '\r',
+	't':  '\t',
+}
+
+func lexString[T text](input T, i int, opts ParseOptions) (token, int, *Error) {
+	start := i
 	i++ // move past the opening quotes
+	// the builder is only used once the string differs from the input, e.g. it has an escape sequence
 	var sb strings.Builder
-	for input[i] != '"' { // TODO: Quote might be escaped
-		sb.WriteByte(input[i])
-		i++
+	decoded := false
+	decode := func() {
+		if !decoded {
+			decoded = true
+			sb.WriteString(string(input[start+1 : i]))
+		}
+	}
+
+	for {
 		if i >= len(input) {
 			return token{}, -1, newError(i, "string is not properly closed")
 		}
+
+		ch := input[i]
+		if ch == '"' {
+			break
+		}
+
+		if ch < 0x20 {
+			return token{}, -1, newError(i, "control characters must be escaped in strings")
+		}
+
+		if ch == '\\' {
+			if i+1 >= len(input) {
+				return token{}, -1, newError(i+1, "string is not properly closed")
+			}
+			decode()
+			if input[i+1] == 'u' {
+				offset, err := lexUnicodeEscape(input, i, opts, &sb)
+				if err != nil {
+					return token{}, -1, err
+				}
+				i += offset
+				continue
+			}
+			escaped, ok := escapeSequences[input[i+1]]
+			if !ok {
+				return token{}, -1, newError(i, fmt.Sprintf("invalid escape sequence: \\%c", input[i+1]))
+			}
+			sb.WriteByte(escaped)
+			i += 2
+			continue
+		}
+
+		if ch >= utf8.RuneSelf && opts.InvalidUTF8 != UTF8Unchecked {
+			if r, size := decodeRune(input, i); r == utf8.RuneError && size == 1 {
+				if opts.InvalidUTF8 == UTF8Reject {
+					return token{}, -1, newError(i, "invalid UTF-8 byte sequence in string")
+				}
+				decode()
+				sb.WriteRune(utf8.RuneError)
+				i++
+			} else {
+				if decoded {
+					sb.WriteString(string(input[i : i+size]))
+				}
+				i += size
+			}
+			continue
+		}
+
+		if decoded {
+			sb.WriteByte(ch)
+		}
+		i++
 	}
 
+	value := sb.String()
+	if !decoded {
+		value = string(input[start+1 : i])
+	}
 	return token{
 			tokenType: ltString,
-			value:     sb.String(),
+			value:     value,
+			start:     start,
+			end:       i + 1,
 		},
-		sb.Len() + 2, // for both the quotes
+		i - start + 1, // including both the quotes
 		nil
 }
+
+// decodeRune decodes the UTF-8 encoded rune at i
+func decodeRune[T text](input T, i int) (rune, int) {
+	switch input := any(input).(type) {
+	case string:
+		return utf8.DecodeRuneInString(input[i:])
+	case []byte:
+		return utf8.DecodeRune(input[i:])
+	}
+	return utf8.RuneError, 1
+}
+
+// lexUnicodeEscape decodes the \uXXXX escape starting at i into sb,
+// combining it with the following escape if the two form a surrogate pair.
+// It returns the number of bytes consumed
+func lexUnicodeEscape[T text](input T, i int, opts ParseOptions, sb *strings.Builder) (int, *Error) {
+	r, err := readHexEscape(input, i)
+	if err != nil {
+		return -1, err
+	}
+
+	if !utf16.IsSurrogate(r) {
+		sb.WriteRune(r)
+		return 6, nil
+	}
+
+	// a high surrogate might be followed by its low counterpart
+	if r < 0xdc00 && i+7 < len(input) && input[i+6] == '\\' && input[i+7] == 'u' {
+		if low, err := readHexEscape(input, i+6); err == nil {
+			if pair := utf16.DecodeRune(r, low); pair != unicode.ReplacementChar {
+				sb.WriteRune(pair)
+				return 12, nil
+			}
+		}
+	}
+
+	switch opts.LoneSurrogates {
+	case SurrogateReplace:
+		sb.WriteRune(unicode.ReplacementChar)
+	case SurrogateWTF8:
+		// utf8 refuses to encode surrogates, so the 3-byte form is written by hand
+		sb.WriteByte(byte(0xe0 | r>>12))
+		sb.WriteByte(byte(0x80 | (r>>6)&0x3f))
+		sb.WriteByte(byte(0x80 | r&0x3f))
+	default:
+		return -1, newError(i, fmt.Sprintf("lone surrogate in unicode escape: %s", string(input[i:i+6])))
+	}
+	return 6, nil
+}
+
+// readHexEscape reads the 4 hex digits of the \uXXXX escape starting at i
+func readHexEscape[T text](input T, i int) (rune, *Error) {
+	if i+6 > len(input) {
+		return 0, newError(i, "invalid unicode escape sequence")
+	}
+
+	var r rune
+	for j := i + 2; j < i+6; j++ {
+		ch := input[j]
+		var digit byte
+		switch {
+		case isDigit(ch):
+			digit = ch - '0'
+		case ch >= 'a' && ch <= 'f':
+			digit = ch - 'a' + 10
+		case ch >= 'A' && ch <= 'F':
+			digit = ch - 'A' + 10
+		default:
+			return 0, newError(i, "invalid unicode escape sequence")
+		}
+		r = r<<4 | rune(digit)
+	}
+	return r, nil
+}
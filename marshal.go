@@ -0,0 +1,286 @@
+package gojson
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+var (
+	jsonValueType  = reflect.TypeOf(JsonValue{})
+	jsonNumberType = reflect.TypeOf(JsonNumber(""))
+)
+
+// Marshal serializes the provided object into compact json text.
+// Structs become objects with their exported fields as members, in the order they are declared,
+// named and configured by their tags as described in encoding/json, e.g. `json:"name,omitempty,string"`.
+// Tags in the gojson namespace take precedence over json tags. The members collected
+// in a field with the extras option are written after the fields, see UnknownFieldCollect.
+// Maps with string or integer keys become objects with their keys sorted.
+// Nil pointers and interfaces become null, while nil slices and maps
+// become empty arrays and objects, so that the output can be unmarshalled back.
+// JsonValue and JsonNumber are written as they are
+func Marshal(v any) ([]byte, error) {
+	return marshal(nil, v)
+}
+
+// marshal appends the json text of the object to buf
+func marshal(buf []byte, v any) ([]byte, error) {
+	m := marshaller{seen: map[uintptr]bool{}}
+	return m.append(buf, reflect.ValueOf(v))
+}
+
+// MarshalJSON serializes the value into compact json text
+func (jv JsonValue) MarshalJSON() ([]byte, error) {
+	return appendValue(nil, jv)
+}
+
+type marshaller struct {
+	// seen holds the pointers and maps on the way to the current value, to detect cycles
+	seen map[uintptr]bool
+}
+
+func (m *marshaller) append(buf []byte, v reflect.Value) ([]byte, error) {
+	if !v.IsValid() {
+		return append(buf, "null"...), nil
+	}
+
+	t := v.Type()
+	switch {
+	case t == jsonValueType:
+		return appendValue(buf, v.Interface().(JsonValue))
+	case t == jsonNumberType:
+		return appendJsonNumber(buf, JsonNumber(v.String()))
+	case isBigNumber(t) && t.Kind() != reflect.Pointer:
+		if !v.CanAddr() {
+			// the methods of the big numbers need a pointer
+			copied := reflect.New(t)
+			copied.Elem().Set(v)
+			v = copied.Elem()
+		}
+		return appendBigNumber(buf, v.Addr().Interface())
+	}
+
+	kind := v.Kind()
+	if class, ok := numbers[kind]; ok {
+		switch class {
+		case signedNumber:
+			return strconv.AppendInt(buf, v.Int(), 10), nil
+		case unsignedNumber:
+			return strconv.AppendUint(buf, v.Uint(), 10), nil
+		default:
+			return appendFloat(buf, v.Float(), t.Bits())
+		}
+	}
+
+	switch kind {
+	case reflect.String:
+		return appendString(buf, v.String()), nil
+	case reflect.Bool:
+		return strconv.AppendBool(buf, v.Bool()), nil
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return append(buf, "null"...), nil
+		}
+		if kind == reflect.Pointer {
+			if m.seen[v.Pointer()] {
+				return nil, errors.New(fmt.Sprintf("cycle detected through a value of type %s", t))
+			}
+			m.seen[v.Pointer()] = true
+			defer delete(m.seen, v.Pointer())
+		}
+		return m.append(buf, v.Elem())
+	case reflect.Slice, reflect.Array:
+		buf = append(buf, '[')
+		for i := 0; i < v.Len(); i++ {
+			if i > 0 {
+				buf = append(buf, ',')
+			}
+			var err error
+			if buf, err = m.append(buf, v.Index(i)); err != nil {
+				return nil, err
+			}
+		}
+		return append(buf, ']'), nil
+	case reflect.Map:
+		if v.IsNil() {
+			return append(buf, "{}"...), nil
+		}
+		// a map can contain itself through an interface
+		if m.seen[v.Pointer()] {
+			return nil, errors.New(fmt.Sprintf("cycle detected through a value of type %s", t))
+		}
+		m.seen[v.Pointer()] = true
+		defer delete(m.seen, v.Pointer())
+		return m.appendMap(buf, v)
+	case reflect.Struct:
+		return m.appendStruct(buf, v)
+	}
+	return nil, errors.New(fmt.Sprintf("unsupported type: %s", t))
+}
+
+func (m *marshaller) appendStruct(buf []byte, v reflect.Value) ([]byte, error) {
+	buf = append(buf, '{')
+	first := true
+	var extras reflect.Value
+	names := map[string]bool{}
+	for _, field := range structFields(v.Type()) {
+		value := v.FieldByIndex(field.index)
+		if field.extras {
+			if !extras.IsValid() {
+				extras = value
+			}
+			continue
+		}
+		names[field.name] = true
+		if field.omitEmpty && isEmptyValue(value) {
+			continue
+		}
+
+		if !first {
+			buf = append(buf, ',')
+		}
+		first = false
+		buf = appendString(buf, field.name)
+		buf = append(buf, ':')
+		var err error
+		if field.asString && quotable(value.Type()) {
+			var literal []byte
+			if literal, err = m.append(nil, value); err != nil {
+				return nil, err
+			}
+			buf = appendString(buf, string(literal))
+		} else if buf, err = m.append(buf, value); err != nil {
+			return nil, err
+		}
+	}
+
+	if extras.IsValid() && extras.Len() > 0 {
+		// the collected members are written back, unless a field took their place
+		members := extras.Interface().(map[string]JsonValue)
+		keys := make([]string, 0, len(members))
+		for key := range members {
+			if !names[key] {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if !first {
+				buf = append(buf, ',')
+			}
+			first = false
+			buf = appendString(buf, key)
+			buf = append(buf, ':')
+			var err error
+			if buf, err = appendValue(buf, members[key]); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return append(buf, '}'), nil
+}
+
+func (m *marshaller) appendMap(buf []byte, v reflect.Value) ([]byte, error) {
+	keyKind := v.Type().Key().Kind()
+	class, isNumber := numbers[keyKind]
+	if keyKind != reflect.String && (!isNumber || class == floatNumber) {
+		return nil, errors.New(fmt.Sprintf("unsupported map key type: %s", v.Type().Key()))
+	}
+
+	type member struct {
+		key   string
+		value reflect.Value
+	}
+	members := make([]member, 0, v.Len())
+	iter := v.MapRange()
+	for iter.Next() {
+		key := iter.Key()
+		var name string
+		switch {
+		case keyKind == reflect.String:
+			name = key.String()
+		case class == signedNumber:
+			name = strconv.FormatInt(key.Int(), 10)
+		default:
+			name = strconv.FormatUint(key.Uint(), 10)
+		}
+		members = append(members, member{name, iter.Value()})
+	}
+	// maps have no order, sorting the keys keeps the output deterministic
+	sort.Slice(members, func(i, j int) bool {
+		return members[i].key < members[j].key
+	})
+
+	buf = append(buf, '{')
+	for i, member := range members {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		buf = appendString(buf, member.key)
+		buf = append(buf, ':')
+		var err error
+		if buf, err = m.append(buf, member.value); err != nil {
+			return nil, err
+		}
+	}
+	return append(buf, '}'), nil
+}
+
+// appendJsonNumber appends the literal of the number, making sure it is valid json
+func appendJsonNumber(buf []byte, n JsonNumber) ([]byte, error) {
+	if _, offset, err := lexNumber(nil, string(n), 0, ParseOptions{}); n == "" || err != nil || offset != len(n) {
+		return nil, errors.New(fmt.Sprintf("invalid number literal: %q", string(n)))
+	}
+	return append(buf, n...), nil
+}
+
+// appendBigNumber appends the exact value of a big.Int, big.Float or big.Rat
+func appendBigNumber(buf []byte, n interface{}) ([]byte, error) {
+	switch n := n.(type) {
+	case *big.Int:
+		return n.Append(buf, 10), nil
+	case *big.Float:
+		if n.IsInf() {
+			return nil, errors.New(fmt.Sprintf("cannot serialize number: %s", n.String()))
+		}
+		return n.Append(buf, 'g', -1), nil
+	case *big.Rat:
+		if n.IsInt() {
+			return n.Num().Append(buf, 10), nil
+		}
+		if digits, ok := decimalDigits(n.Denom()); ok {
+			return append(buf, n.FloatString(digits)...), nil
+		}
+		// numbers like 1/3 have no exact decimal form
+		f, _ := n.Float64()
+		return appendFloat(buf, f, 64)
+	}
+	return nil, errors.New(fmt.Sprintf("unsupported number: %v", n))
+}
+
+// decimalDigits returns the number of fractional digits a fraction with the denominator needs,
+// if it has a finite decimal form, which is the case when its only prime factors are 2 and 5
+func decimalDigits(denominator *big.Int) (int, bool) {
+	d := new(big.Int).Set(denominator)
+	two, five := big.NewInt(2), big.NewInt(5)
+	var twos, fives int
+	for new(big.Int).Mod(d, two).Sign() == 0 {
+		d.Quo(d, two)
+		twos++
+	}
+	for new(big.Int).Mod(d, five).Sign() == 0 {
+		d.Quo(d, five)
+		fives++
+	}
+	if d.Cmp(big.NewInt(1)) != 0 {
+		return 0, false
+	}
+	if twos > fives {
+		return twos, true
+	}
+	return fives, true
+}
@@ -0,0 +1,285 @@
+package gojson
+
+import (
+	"math"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type address struct {
+	Street string
+	Number uint16
+}
+
+type person struct {
+	Name      string
+	Age       int8
+	Height    float32
+	Admin     bool
+	Tags      []string
+	Address   *address
+	Manager   *person
+	Scores    map[string]float64
+	ignored   string
+	Anything  interface{}
+	Raw       JsonValue
+	Balance   big.Int
+	Precision *big.Rat
+}
+
+func TestMarshal(t *testing.T) {
+	p := person{
+		Name:      "Jane \"J\" Doe\n",
+		Age:       -42,
+		Height:    1.7,
+		Admin:     true,
+		Tags:      []string{"a", "b"},
+		Address:   &address{"Main", 12},
+		Scores:    map[string]float64{"z": 0.1, "a": 1e21},
+		ignored:   "not exported",
+		Anything:  []interface{}{1, "x", nil},
+		Raw:       JsonValue{Value: JsonNumber("1.50"), ValueType: NUMBER},
+		Precision: big.NewRat(3, 8),
+	}
+	p.Balance.SetString("123456789012345678901234567890", 10)
+
+	data, err := Marshal(p)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `{"Name":"Jane \"J\" Doe\n","Age":-42,"Height":1.7,"Admin":true,"Tags":["a","b"],` +
+		`"Address":{"Street":"Main","Number":12},"Manager":null,"Scores":{"a":1e+21,"z":0.1},` +
+		`"Anything":[1,"x",null],"Raw":1.50,"Balance":123456789012345678901234567890,"Precision":0.375}`
+	if string(data) != expected {
+		t.Fatalf("expected:\n%s\ngot:\n%s", expected, data)
+	}
+}
+
+func TestMarshalValues(t *testing.T) {
+	var data = []struct {
+		value    any
+		expected string
+	}{
+		{nil, `null`},
+		{" é\x01\t", "\" é\\u0001\\t\""},
+		{"a\xffb\xed\xa0\x80", `"a\ufffdb\ufffd\ufffd\ufffd"`},
+		{uint64(math.MaxUint64), `18446744073709551615`},
+		{math.MaxInt64, `9223372036854775807`},
+		{0.30000000000000004, `0.30000000000000004`},
+		{float32(0.1), `0.1`},
+		{1e-7, `1e-7`},
+		{123456789.0, `123456789`},
+		{[]int(nil), `[]`},
+		{map[string]int(nil), `{}`},
+		{map[int]bool{10: true, 2: false}, `{"10":true,"2":false}`},
+		{[2]bool{true, false}, `[true,false]`},
+		{JsonNumber("-0.5e10"), `-0.5e10`},
+		{big.NewFloat(2.5), `2.5`},
+		{big.NewRat(1, 3), `0.3333333333333333`},
+		{&address{"x", 1}, `{"Street":"x","Number":1}`},
+	}
+
+	for _, data := range data {
+		t.Run(data.expected, func(t *testing.T) {
+			out, err := Marshal(data.value)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(out) != data.expected {
+				t.Fatalf("expected: %s, got: %s", data.expected, out)
+			}
+		})
+	}
+}
+
+func TestMarshalErrors(t *testing.T) {
+	cyclic := &person{}
+	cyclic.Manager = cyclic
+	selfContaining := map[string]interface{}{}
+	selfContaining["self"] = selfContaining
+
+	var data = map[string]struct {
+		value    any
+		expected string
+	}{
+		`channels`:              {make(chan int), "unsupported type: chan int"},
+		`functions`:             {[]func(){nil}, "unsupported type: func()"},
+		`infinity`:              {math.Inf(1), "cannot serialize number: +Inf"},
+		`not a number`:          {math.NaN(), "cannot serialize number: NaN"},
+		`float map keys`:        {map[float64]int{1: 1}, "unsupported map key type: float64"},
+		`invalid literal`:       {JsonNumber("01"), `invalid number literal: "01"`},
+		`cyclic pointers`:       {cyclic, "cycle detected through a value of type *gojson.person"},
+		`cyclic maps`:           {selfContaining, "cycle detected through a value of type map[string]interface {}"},
+		`invalid values`:        {JsonValue{ValueType: INVALID}, "cannot serialize value of type INVALID"},
+		`invalid value literal`: {JsonValue{Value: JsonNumber("abc"), ValueType: NUMBER}, `invalid number literal: "abc"`},
+		`bare sign literal`:     {JsonValue{Value: JsonNumber("-"), ValueType: NUMBER}, `invalid number literal: "-"`},
+		`integer value`:         {JsonValue{Value: 1, ValueType: NUMBER}, "cannot serialize number of type int"},
+	}
+
+	for name, data := range data {
+		t.Run(name, func(t *testing.T) {
+			_, err := Marshal(data.value)
+			if err == nil || err.Error() != data.expected {
+				t.Fatalf("expected: %s, got: %v", data.expected, err)
+			}
+		})
+	}
+}
+
+func TestMarshalRoundTrip(t *testing.T) {
+	type config struct {
+		Hostname string
+		Port     int
+		Ratio    float64
+		Enabled  bool
+		Aliases  []string
+		Limits   []uint32
+		Nested   address
+	}
+
+	original := config{
+		Hostname: "localhost",
+		Port:     8282,
+		Ratio:    1.0 / 3,
+		Enabled:  true,
+		Aliases:  []string{"a", "b"},
+		Limits:   []uint32{math.MaxUint32},
+		Nested:   address{"Main", 7},
+	}
+
+	data, err := Marshal(original)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var decoded config
+	if err := Unmarshal(string(data), &decoded); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(original, decoded) {
+		t.Fatalf("expected: %+v, got: %+v", original, decoded)
+	}
+}
+
+func TestJsonValueMarshalJSON(t *testing.T) {
+	input := `{"b": [1.5, "x\n", null, true], "a": {}}`
+	json, err := Parse(input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, merr := json.MarshalJSON()
+	if merr != nil {
+		t.Fatalf("unexpected error: %v", merr)
+	}
+	expected := `{"a":{},"b":[1.5,"x\n",null,true]}`
+	if string(data) != expected {
+		t.Fatalf("expected: %s, got: %s", expected, data)
+	}
+
+	reparsed, err := Parse(string(data))
+	if err != nil || !reflect.DeepEqual(reparsed, json) {
+		t.Fatalf("expected: %v, got: %v, %v", json, reparsed, err)
+	}
+
+	// the literals of lenient numbers are written as valid json
+	lenient, err := ParseWithOptions(`[0123, -007.5, +00, 0e5]`, ParseOptions{LenientNumbers: true, UseNumber: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if data, merr = lenient.MarshalJSON(); merr != nil || string(data) != `[123,-7.5,0,0e5]` {
+		t.Fatalf("expected: [123,-7.5,0,0e5], got: %s, %v", data, merr)
+	}
+}
+
+// floats are written with as few digits as possible, but parse back to the same value
+func FuzzMarshalFloat(f *testing.F) {
+	for _, seed := range []float64{0, -0.0, 0.1, 1e21, 5e-324, math.MaxFloat64, 123456.789e-10} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, value float64) {
+		if math.IsInf(value, 0) || math.IsNaN(value) {
+			return
+		}
+		data, err := Marshal(value)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		json, perr := Parse(string(data))
+		if perr != nil {
+			t.Fatalf("%s is not valid json: %v", data, perr)
+		}
+		if json.Value != value || strings.Contains(string(data), "e-0") {
+			t.Fatalf("expected: %v, got: %s", value, data)
+		}
+	})
+}
+
+func TestMarshalStructTags(t *testing.T) {
+	balance, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	a := account{
+		timestamps: timestamps{Created: 1700000000},
+		ID:         18446744073709551615,
+		Name:       "Jane",
+		Active:     true,
+		Password:   "secret",
+		Balance:    balance,
+		internal:   "not exported",
+	}
+
+	data, err := Marshal(a)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `{"created_at":1700000000,"id":"18446744073709551615","display_name":"Jane",` +
+		`"Active":"true","-":"","balance":"123456789012345678901234567890"}`
+	if string(data) != expected {
+		t.Fatalf("expected:\n%s\ngot:\n%s", expected, data)
+	}
+
+	var decoded account
+	if err := Unmarshal(string(data), &decoded); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	a.Password, a.internal = "", ""
+	if !reflect.DeepEqual(decoded, a) {
+		t.Fatalf("expected: %+v, got: %+v", a, decoded)
+	}
+}
+
+func TestMarshalOmitEmpty(t *testing.T) {
+	type optional struct {
+		Text    string            `json:"text,omitempty"`
+		Count   int               `json:"count,omitempty"`
+		Ratio   float32           `json:"ratio,omitempty"`
+		Flag    bool              `json:"flag,omitempty"`
+		List    []int             `json:"list,omitempty"`
+		Map     map[string]int    `json:"map,omitempty"`
+		Pointer *address          `json:"pointer,omitempty"`
+		Any     interface{}       `json:"any,omitempty"`
+		Nested  address           `json:"nested,omitempty"`
+		Kept    map[string]string `json:"kept"`
+	}
+
+	data, err := Marshal(optional{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `{"nested":{"Street":"","Number":0},"kept":{}}`
+	if string(data) != expected {
+		t.Fatalf("expected: %s, got: %s", expected, data)
+	}
+
+	data, err = Marshal(optional{Count: -1, List: []int{0}, Any: 0})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected = `{"count":-1,"list":[0],"any":0,"nested":{"Street":"","Number":0},"kept":{}}`
+	if string(data) != expected {
+		t.Fatalf("expected: %s, got: %s", expected, data)
+	}
+}
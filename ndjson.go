@@ -0,0 +1,182 @@
+package gojson
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// NDJSONOptions configures the behaviour of NDJSONReader.
+// The zero value stops at the first malformed line
+type NDJSONOptions struct {
+	// Unmarshal configures how the lines are parsed and deserialized
+	Unmarshal UnmarshalOptions
+	// SkipInvalidLines makes the reader skip the lines that cannot be parsed
+	// or deserialized instead of stopping. The errors are kept, see NDJSONReader.Errors
+	SkipInvalidLines bool
+}
+
+// LineError is an error found in a line of newline-delimited json
+type LineError struct {
+	// Line is the 1-based number of the line
+	Line int
+	Err  error
+}
+
+func (le *LineError) Error() string {
+	var err *Error
+	if errors.As(le.Err, &err) && err.Line != 0 {
+		// syntax errors are located in the whole input already
+		return err.Error()
+	}
+	return fmt.Sprintf("line %d: %s", le.Line, le.Err)
+}
+
+func (le *LineError) Unwrap() error {
+	return le.Err
+}
+
+// NDJSONReader reads newline-delimited json, also known as JSON Lines:
+// one json value on every line. Blank lines are ignored
+type NDJSONReader struct {
+	reader *bufio.Reader
+	opts   NDJSONOptions
+
+	line      int // the number of the last line read
+	lineStart int // the offset of the last line read in the input
+	offset    int // the offset of the next line in the input
+	errs      []error
+	err       error // the error the reader stopped at
+}
+
+// NewNDJSONReader returns a reader of the newline-delimited json read from r
+func NewNDJSONReader(r io.Reader) *NDJSONReader {
+	return NewNDJSONReaderWithOptions(r, NDJSONOptions{})
+}
+
+// NewNDJSONReaderWithOptions works like NewNDJSONReader, but lets the caller
+// configure how the lines are parsed and what happens to malformed lines
+func NewNDJSONReaderWithOptions(r io.Reader, opts NDJSONOptions) *NDJSONReader {
+	return &NDJSONReader{reader: bufio.NewReader(r), opts: opts}
+}
+
+// Read parses the value on the next line. It returns io.EOF
+// if there are no more lines, and a *LineError if the line is malformed
+func (r *NDJSONReader) Read() (JsonValue, error) {
+	return r.next(nil)
+}
+
+// Decode reads the value on the next line and deserializes it into the provided object,
+// which needs to be a pointer. If the value cannot be deserialized and invalid lines are skipped,
+// the object may have been partially filled in by the time the next line is decoded into it
+func (r *NDJSONReader) Decode(ptr any) error {
+	_, err := r.next(ptr)
+	return err
+}
+
+// Line returns the number of the line the last value was read from
+func (r *NDJSONReader) Line() int {
+	return r.line
+}
+
+// Errors returns the errors of the lines that have been skipped so far
+func (r *NDJSONReader) Errors() []error {
+	return r.errs
+}
+
+func (r *NDJSONReader) next(ptr any) (JsonValue, error) {
+	for r.err == nil {
+		input, err := r.readLine()
+		if err != nil {
+			r.err = err
+			break
+		}
+		if strings.TrimLeft(input, " \t\r\n") == "" {
+			continue
+		}
+
+		json, lerr := r.parseLine(input, ptr)
+		if lerr == nil {
+			return json, nil
+		}
+		if !r.opts.SkipInvalidLines {
+			r.err = lerr
+			break
+		}
+		r.errs = append(r.errs, lerr)
+	}
+	return JsonValue{}, r.err
+}
+
+// readLine reads the next line without its line ending
+func (r *NDJSONReader) readLine() (string, error) {
+	line, err := r.reader.ReadString('\n')
+	if err == io.EOF && line != "" {
+		// the last line does not have to end with a new line
+		err = nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	r.line++
+	r.lineStart = r.offset
+	r.offset += len(line)
+	line = strings.TrimSuffix(line, "\n")
+	return strings.TrimSuffix(line, "\r"), nil
+}
+
+func (r *NDJSONReader) parseLine(input string, ptr any) (JsonValue, *LineError) {
+	json, err := ParseWithOptions(input, r.opts.Unmarshal.Parse)
+	if err != nil {
+		// move the error from the line to where it is in the whole input
+		if err.Offset >= 0 {
+			err.Offset += r.lineStart
+			err.Pos = err.Offset
+			err.Line = r.line
+		}
+		return JsonValue{}, &LineError{r.line, err}
+	}
+
+	if ptr != nil {
+		if err := json.UnmarshalWithOptions(ptr, r.opts.Unmarshal); err != nil {
+			return JsonValue{}, &LineError{r.line, err}
+		}
+	}
+	return json, nil
+}
+
+// NDJSONWriter writes newline-delimited json, one value per line
+type NDJSONWriter struct {
+	writer io.Writer
+	buf    []byte
+}
+
+// NewNDJSONWriter returns a writer of newline-delimited json writing to w
+func NewNDJSONWriter(w io.Writer) *NDJSONWriter {
+	return &NDJSONWriter{writer: w}
+}
+
+// WriteValue writes the value in its compact form, followed by a new line
+func (w *NDJSONWriter) WriteValue(jv JsonValue) error {
+	buf, err := appendValue(w.buf[:0], jv)
+	if err != nil {
+		return err
+	}
+	w.buf = append(buf, '\n')
+	_, err = w.writer.Write(w.buf)
+	return err
+}
+
+// Encode serializes the object with Marshal and writes it, followed by a new line
+func (w *NDJSONWriter) Encode(v any) error {
+	buf, err := marshal(w.buf[:0], v)
+	if err != nil {
+		return err
+	}
+	w.buf = append(buf, '\n')
+	_, err = w.writer.Write(w.buf)
+	return err
+}
@@ -0,0 +1,168 @@
+package gojson
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNDJSONReader(t *testing.T) {
+	input := "{\"a\": 1}\r\n\n  \n[true, null]\n\"x\""
+
+	r := NewNDJSONReader(strings.NewReader(input))
+	var values []JsonValue
+	var lines []int
+	for {
+		json, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		values = append(values, json)
+		lines = append(lines, r.Line())
+	}
+
+	expected := []JsonValue{
+		{map[string]JsonValue{"a": {float64(1), NUMBER}}, OBJECT},
+		{[]JsonValue{{true, BOOL}, {nil, NULL}}, ARRAY},
+		{"x", STRING},
+	}
+	if !reflect.DeepEqual(values, expected) {
+		t.Fatalf("expected: %v, got: %v", expected, values)
+	}
+	if !reflect.DeepEqual(lines, []int{1, 4, 5}) {
+		t.Fatalf("expected the lines [1 4 5], got: %v", lines)
+	}
+}
+
+type logEntry struct {
+	Level   string
+	Message string
+}
+
+func TestNDJSONReaderErrors(t *testing.T) {
+	input := `{"Level": "info", "Message": "started"}
+{"Level": "warn" "Message": "disk"}
+{"Level": 3, "Message": "wrong type"}
+{"Level": "error", "Message": "stopped"}
+`
+
+	t.Run("stops at the first malformed line", func(t *testing.T) {
+		r := NewNDJSONReader(strings.NewReader(input))
+		var entry logEntry
+		if err := r.Decode(&entry); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		err := r.Decode(&entry)
+		var lineErr *LineError
+		if !errors.As(err, &lineErr) || lineErr.Line != 2 {
+			t.Fatalf("expected an error in line 2, got: %v", err)
+		}
+		expected := "expected ',' or '}' after object member, found string literal at 2:18"
+		if err.Error() != expected {
+			t.Fatalf("expected: %s, got: %s", expected, err)
+		}
+		var syntaxErr *Error
+		if !errors.As(err, &syntaxErr) || syntaxErr.Offset != 57 || syntaxErr.Pos != 57 {
+			t.Fatalf("expected the error at offset 57, got: %v", syntaxErr)
+		}
+		if snippet := "2 | {\"Level\": \"warn\" \"Message\": \"disk\"}\n  |                  ^"; syntaxErr.Snippet() != snippet {
+			t.Fatalf("expected snippet:\n%s\ngot:\n%s", snippet, syntaxErr.Snippet())
+		}
+
+		// the reader does not carry on after an error
+		if again := r.Decode(&entry); again != err {
+			t.Fatalf("expected the same error again, got: %v", again)
+		}
+	})
+
+	t.Run("malformed lines can be skipped", func(t *testing.T) {
+		r := NewNDJSONReaderWithOptions(strings.NewReader(input), NDJSONOptions{SkipInvalidLines: true})
+		var entries []logEntry
+		for {
+			var entry logEntry
+			err := r.Decode(&entry)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			entries = append(entries, entry)
+		}
+
+		expected := []logEntry{{"info", "started"}, {"error", "stopped"}}
+		if !reflect.DeepEqual(entries, expected) {
+			t.Fatalf("expected: %v, got: %v", expected, entries)
+		}
+
+		errs := r.Errors()
+		if len(errs) != 2 {
+			t.Fatalf("expected 2 errors, got: %v", errs)
+		}
+		expectedErr := "line 3: Level: type mismatch: expected: NUMBER, provided: STRING"
+		if errs[1].Error() != expectedErr {
+			t.Fatalf("expected: %s, got: %s", expectedErr, errs[1])
+		}
+	})
+}
+
+func TestNDJSONWriter(t *testing.T) {
+	var out bytes.Buffer
+	w := NewNDJSONWriter(&out)
+
+	values := []JsonValue{
+		{map[string]JsonValue{"b": {"multi\nline", STRING}, "a": {float64(1.5), NUMBER}}, OBJECT},
+		{[]JsonValue{{true, BOOL}, {nil, NULL}, {JsonNumber("1e999"), NUMBER}}, ARRAY},
+	}
+	for _, value := range values {
+		if err := w.WriteValue(value); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	expected := "{\"a\":1.5,\"b\":\"multi\\nline\"}\n[true,null,1e999]\n"
+	if out.String() != expected {
+		t.Fatalf("expected: %q, got: %q", expected, out.String())
+	}
+
+	// what is written can be read back
+	r := NewNDJSONReaderWithOptions(&out, NDJSONOptions{Unmarshal: UnmarshalOptions{Parse: ParseOptions{UseNumber: true}}})
+	first, err := r.Read()
+	if err != nil || first.Value.(map[string]JsonValue)["b"].Value != "multi\nline" {
+		t.Fatalf("unexpected value: %v, %v", first, err)
+	}
+
+	if err := w.WriteValue(JsonValue{Value: errors.New("bad"), ValueType: INVALID}); err == nil {
+		t.Fatalf("expected an error for invalid values")
+	}
+}
+
+func TestNDJSONWriterEncode(t *testing.T) {
+	type event struct {
+		Name  string
+		Count int
+	}
+
+	var out bytes.Buffer
+	w := NewNDJSONWriter(&out)
+	for _, value := range []any{event{"start", 1}, []int{1, 2}, nil} {
+		if err := w.Encode(value); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if err := w.Encode(make(chan int)); err == nil {
+		t.Fatalf("expected an error for unsupported types")
+	}
+
+	expected := "{\"Name\":\"start\",\"Count\":1}\n[1,2]\nnull\n"
+	if out.String() != expected {
+		t.Fatalf("expected: %q, got: %q", expected, out.String())
+	}
+}
@@ -0,0 +1,100 @@
+package gojson
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+)
+
+// JsonNumber is a json number kept as its exact decimal literal
+type JsonNumber string
+
+// String returns the literal of the number
+func (n JsonNumber) String() string {
+	return string(n)
+}
+
+// Float64 returns the number as the closest float64
+func (n JsonNumber) Float64() (float64, error) {
+	return strconv.ParseFloat(string(n), 64)
+}
+
+// Int64 returns the number as an int64.
+// Literals with a fraction or an exponent are accepted
+// as long as their value is an integer, e.g. 1.5e3
+func (n JsonNumber) Int64() (int64, error) {
+	if i, err := strconv.ParseInt(string(n), 10, 64); err == nil {
+		return i, nil
+	}
+	bi, err := n.BigInt()
+	if err != nil {
+		return 0, err
+	}
+	if !bi.IsInt64() {
+		return 0, errors.New(fmt.Sprintf("number %s overflows int64", n))
+	}
+	return bi.Int64(), nil
+}
+
+// Uint64 returns the number as an uint64.
+// Literals with a fraction or an exponent are accepted
+// as long as their value is a non-negative integer, e.g. 1.5e3
+func (n JsonNumber) Uint64() (uint64, error) {
+	if i, err := strconv.ParseUint(string(n), 10, 64); err == nil {
+		return i, nil
+	}
+	bi, err := n.BigInt()
+	if err != nil {
+		return 0, err
+	}
+	if !bi.IsUint64() {
+		return 0, errors.New(fmt.Sprintf("number %s overflows uint64", n))
+	}
+	return bi.Uint64(), nil
+}
+
+// BigInt returns the number as an arbitrary precision integer.
+// It fails if the number has a non-zero fractional part
+func (n JsonNumber) BigInt() (*big.Int, error) {
+	r, err := n.Rat()
+	if err != nil {
+		return nil, err
+	}
+	if !r.IsInt() {
+		return nil, errors.New(fmt.Sprintf("number %s is not an integer", n))
+	}
+	return new(big.Int).Set(r.Num()), nil
+}
+
+// BigFloat returns the number as an arbitrary precision float.
+// The precision is chosen so that every digit of the literal is kept
+func (n JsonNumber) BigFloat() (*big.Float, error) {
+	precision := uint(len(n))*4 + 64
+	f, _, err := big.ParseFloat(string(n), 10, precision, big.ToNearestEven)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("invalid number: %s", n))
+	}
+	return f, nil
+}
+
+// Rat returns the number as an exact rational number
+func (n JsonNumber) Rat() (*big.Rat, error) {
+	r, ok := new(big.Rat).SetString(string(n))
+	if !ok {
+		return nil, errors.New(fmt.Sprintf("invalid number: %s", n))
+	}
+	return r, nil
+}
+
+// asJsonNumber returns the value of a NUMBER as a JsonNumber,
+// regardless of whether it was parsed as a float64 or kept exact
+func asJsonNumber(value interface{}) (JsonNumber, bool) {
+	switch n := value.(type) {
+	case JsonNumber:
+		return n, true
+	case float64:
+		return JsonNumber(strconv.FormatFloat(n, 'g', -1, 64)), true
+	}
+	return "", false
+}
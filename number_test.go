@@ -0,0 +1,149 @@
+package gojson
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+func TestUseNumber(t *testing.T) {
+	json, err := ParseWithOptions(`{"id": 9007199254740993, "ratio": 0.1, "exp": -1.5E+3}`, ParseOptions{UseNumber: true})
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+	expected := JsonValue{
+		ValueType: OBJECT,
+		Value: map[string]JsonValue{
+			"id":    {ValueType: NUMBER, Value: JsonNumber("9007199254740993")},
+			"ratio": {ValueType: NUMBER, Value: JsonNumber("0.1")},
+			"exp":   {ValueType: NUMBER, Value: JsonNumber("-1.5E+3")},
+		},
+	}
+	if !reflect.DeepEqual(json, expected) {
+		t.Fatalf("expected: %v, got: %v", expected, json)
+	}
+}
+
+func TestJsonNumber(t *testing.T) {
+	t.Run("Int64", func(t *testing.T) {
+		var testCases = map[JsonNumber]int64{
+			"9007199254740993":     9007199254740993,
+			"-9223372036854775808": -9223372036854775808,
+			"1.5e3":                1500,
+			"120E-1":               12,
+			"-0":                   0,
+		}
+		for n, expected := range testCases {
+			if i, err := n.Int64(); err != nil || i != expected {
+				t.Errorf("%s: expected: %d, got: %d, %v", n, expected, i, err)
+			}
+		}
+		for _, n := range []JsonNumber{"1.5", "9223372036854775808", "1e19"} {
+			if _, err := n.Int64(); err == nil {
+				t.Errorf("%s: error value was required", n)
+			}
+		}
+	})
+
+	t.Run("Uint64", func(t *testing.T) {
+		if i, err := JsonNumber("18446744073709551615").Uint64(); err != nil || i != 18446744073709551615 {
+			t.Errorf("expected: max uint64, got: %d, %v", i, err)
+		}
+		for _, n := range []JsonNumber{"-1", "18446744073709551616", "0.5"} {
+			if _, err := n.Uint64(); err == nil {
+				t.Errorf("%s: error value was required", n)
+			}
+		}
+	})
+
+	t.Run("Float64", func(t *testing.T) {
+		if f, err := JsonNumber("-0.000123").Float64(); err != nil || f != -0.000123 {
+			t.Errorf("expected: -0.000123, got: %v, %v", f, err)
+		}
+		if _, err := JsonNumber("1e400").Float64(); err == nil {
+			t.Errorf("error value was required")
+		}
+	})
+
+	t.Run("BigInt", func(t *testing.T) {
+		bi, err := JsonNumber("123456789012345678901234567890").BigInt()
+		if err != nil || bi.String() != "123456789012345678901234567890" {
+			t.Errorf("unexpected: %v, %v", bi, err)
+		}
+		if bi, err := JsonNumber("1e30").BigInt(); err != nil || bi.String() != "1000000000000000000000000000000" {
+			t.Errorf("unexpected: %v, %v", bi, err)
+		}
+		if _, err := JsonNumber("0.5").BigInt(); err == nil {
+			t.Errorf("error value was required")
+		}
+	})
+
+	t.Run("BigFloat", func(t *testing.T) {
+		bf, err := JsonNumber("1.000000000000000000000000001").BigFloat()
+		if err != nil || bf.Text('f', 27) != "1.000000000000000000000000001" {
+			t.Errorf("unexpected: %v, %v", bf, err)
+		}
+	})
+
+	t.Run("Rat", func(t *testing.T) {
+		r, err := JsonNumber("0.1").Rat()
+		if err != nil || r.Cmp(big.NewRat(1, 10)) != 0 {
+			t.Errorf("unexpected: %v, %v", r, err)
+		}
+		if r, err := JsonNumber("-2.5e-1").Rat(); err != nil || r.Cmp(big.NewRat(-1, 4)) != 0 {
+			t.Errorf("unexpected: %v, %v", r, err)
+		}
+	})
+}
+
+type Account struct {
+	ID      int64
+	Counter uint64
+	Balance *big.Rat
+	Supply  big.Int
+	Rate    *big.Float
+}
+
+func TestUnmarshalExactNumbers(t *testing.T) {
+	input := `{"ID": 9007199254740993, "Counter": 18446744073709551615, "Balance": 10.25, "Supply": 123456789012345678901234567890, "Rate": 0.5}`
+	json, err := ParseWithOptions(input, ParseOptions{UseNumber: true})
+	if err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+
+	var account Account
+	if err := json.Unmarshal(&account); err != nil {
+		t.Fatalf("%s", err.Error())
+	}
+
+	if account.ID != 9007199254740993 || account.Counter != 18446744073709551615 {
+		t.Errorf("precision lost: %d, %d", account.ID, account.Counter)
+	}
+	if account.Balance.Cmp(big.NewRat(41, 4)) != 0 {
+		t.Errorf("unexpected balance: %v", account.Balance)
+	}
+	if account.Supply.String() != "123456789012345678901234567890" {
+		t.Errorf("unexpected supply: %v", account.Supply.String())
+	}
+	if f, _ := account.Rate.Float64(); f != 0.5 {
+		t.Errorf("unexpected rate: %v", account.Rate)
+	}
+
+	t.Run("big numbers from float64 values", func(t *testing.T) {
+		var values []*big.Int
+		if err := Unmarshal(`[1, 25e2]`, &values); err != nil {
+			t.Fatalf("%s", err.Error())
+		}
+		if fmt.Sprint(values) != "[1 2500]" {
+			t.Errorf("unexpected: %v", values)
+		}
+	})
+
+	t.Run("big number from a string", func(t *testing.T) {
+		var bi big.Int
+		if err := Unmarshal(`"1"`, &bi); err == nil {
+			t.Errorf("error value was required")
+		}
+	})
+}
@@ -0,0 +1,63 @@
+package gojson
+
+// SurrogatePolicy decides what happens to a \uXXXX escape
+// that encodes a UTF-16 surrogate without its other half
+type SurrogatePolicy = uint8
+
+const (
+	// SurrogateError rejects lone surrogates with an error
+	SurrogateError SurrogatePolicy = 0
+	// SurrogateReplace replaces lone surrogates with U+FFFD
+	SurrogateReplace SurrogatePolicy = 1
+	// SurrogateWTF8 keeps lone surrogates, encoded as WTF-8
+	SurrogateWTF8 SurrogatePolicy = 2
+)
+
+// UTF8Policy decides what happens to invalid UTF-8 byte sequences inside strings
+type UTF8Policy = uint8
+
+const (
+	// UTF8Unchecked copies the bytes of strings as they are
+	UTF8Unchecked UTF8Policy = 0
+	// UTF8Reject rejects invalid byte sequences with an error
+	UTF8Reject UTF8Policy = 1
+	// UTF8Replace replaces every invalid byte with U+FFFD
+	UTF8Replace UTF8Policy = 2
+)
+
+// OverflowPolicy decides what happens to numbers
+// that do not fit into a float64
+type OverflowPolicy = uint8
+
+const (
+	// OverflowError rejects out of range numbers with an error
+	OverflowError OverflowPolicy = 0
+	// OverflowClamp turns out of range numbers into ±Inf
+	OverflowClamp OverflowPolicy = 1
+	// OverflowExact keeps out of range numbers as a JsonNumber
+	// holding the exact decimal literal
+	OverflowExact OverflowPolicy = 2
+)
+
+// ParseOptions configures the behaviour of ParseWithOptions.
+// The zero value results in the same behaviour as Parse
+type ParseOptions struct {
+	// LoneSurrogates is the policy for unpaired surrogate escapes
+	LoneSurrogates SurrogatePolicy
+	// InvalidUTF8 is the policy for invalid UTF-8 inside strings
+	InvalidUTF8 UTF8Policy
+	// AllowBOM makes the parser skip a leading UTF-8 byte order mark
+	// instead of rejecting the input
+	AllowBOM bool
+	// LenientNumbers accepts numbers with a leading plus sign
+	// or leading zeros, which RFC 8259 forbids
+	LenientNumbers bool
+	// NumberOverflow is the policy for numbers out of the float64 range
+	NumberOverflow OverflowPolicy
+	// UseNumber keeps every number as a JsonNumber holding
+	// the exact literal, instead of converting it to float64
+	UseNumber bool
+	// Tracer, if set, is notified of every shift, reduction and the final
+	// decision of the parser. It is ignored by ParseAll
+	Tracer Tracer
+}
@@ -1,67 +1,67 @@
 package gojson
 
-import "fmt"
-
 // Parse takes a json string as an input and returns a tuple of
 // parsed json in the form of JsonValue or
 // a possible error encountered while parsing the input
 func Parse(input string) (JsonValue, *Error) {
-	tokens, err := lex(input)
+	return ParseWithOptions(input, ParseOptions{})
+}
 
+// ParseWithOptions works like Parse, but lets the caller
+// configure how the input is interpreted
+func ParseWithOptions(input string, opts ParseOptions) (JsonValue, *Error) {
+	json, err := parse(input, opts)
 	if err != nil {
-		return JsonValue{}, err
+		return JsonValue{}, err.locate(input)
 	}
+	return json, nil
+}
 
-	var stack []*stackElement
-
-	size := len(tokens)
-	reducePerformed := true
+func parse(input string, opts ParseOptions) (JsonValue, *Error) {
+	tokens, err := lex(input, opts)
 
-	for i := 0; i < size; {
-		lookahead := tokens[i]
+	if err != nil {
+		return JsonValue{}, err
+	}
 
-		if matchType := checkIfAnyPrefixExists(stack, lookahead); matchType != noMatch {
-			i++
-			stack = append(stack, &stackElement{value: lookahead})
+	return build(tokens, input, &opts, nil)
+}
 
-			if matchType == partialMatch {
-				continue
-			}
-			// full match means that there's something we can reduce now
-		} else if !reducePerformed {
-			return JsonValue{}, newError(-1, fmt.Sprintf("unexpected token: %s", lookahead.tokenType))
-		}
+// build runs the tokens through the parsing tables, turning them into a JsonValue.
+// If errs is not nil, the errors of the reductions are collected there
+// and the values that caused them are marked as invalid
+func build(tokens []token, input string, opts *ParseOptions, errs *[]*Error) (JsonValue, *Error) {
+	r := newRecognizer()
+	r.opts = opts
+	r.errs = errs
+	r.tracer = opts.Tracer
 
-		if jsonElement, offset := action(stack); offset != 0 {
-			stack = stack[:len(stack)-offset]
-			stack = append(stack, &stackElement{
-				rule: jsonElement,
-			})
-			reducePerformed = true
-		} else {
-			reducePerformed = false
-		}
+	if err := r.run(tokens, input); err != nil {
+		return JsonValue{}, err
 	}
+	// the only thing left on the stack is the <value> of the whole input
+	return r.values[0].asJsonValue(), nil
+}
 
-	for {
-		if jsonElement, offset := action(stack); offset != 0 {
-			stack = stack[:len(stack)-offset]
-			stack = append(stack, &stackElement{
-				rule: jsonElement,
+// run feeds the tokens to the recognizer until the whole input is accepted
+func (r *recognizer) run(tokens []token, input string) *Error {
+	r.input = input
+	for i := 0; ; i++ {
+		lookahead := tokenAt(tokens, i, len(input))
+		if !r.accepts(lookahead.tokenType) {
+			err := r.unexpectedToken(lookahead)
+			r.trace(TraceReject, lookahead, func(step *TraceStep) {
+				step.Err = err
 			})
-		} else {
-			break
+			return err
 		}
-	}
 
-	if len(stack) != 1 {
-		return JsonValue{}, newError(-1, "parsing failed...")
-	}
-
-	values := stack[0].rule.value.(JsonValue).Value.([]JsonValue)
-	if len(values) != 1 {
-		return JsonValue{}, newError(-1, "parsing failed...")
+		done, err := r.feed(lookahead)
+		if err != nil {
+			return err
+		}
+		if done {
+			return nil
+		}
 	}
-
-	return values[0], nil
 }
@@ -2,8 +2,11 @@ package gojson
 
 import (
 	"fmt"
+	"math"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestParse(t *testing.T) {
@@ -186,15 +189,35 @@ func TestErrorHandling(t *testing.T) {
 	}{
 		`nill should be null`: {
 			input:    `{"hello": nill}`,
-			errorMsg: "unrecognized token at position 10",
+			errorMsg: "unrecognized token at 1:11",
 		},
 		`improperly closed array expression`: {
 			input:    `{"value": [1239,12345}`,
-			errorMsg: "unexpected token: }",
+			errorMsg: "expected ',' or ']' after array element, found '}' at 1:22",
+		},
+		`truncated true`: {
+			input:    `[tr`,
+			errorMsg: "unrecognized token at 1:2",
+		},
+		`truncated false`: {
+			input:    `{"a": fals`,
+			errorMsg: "unrecognized token at 1:7",
+		},
+		`trailing n`: {
+			input:    `[1, n`,
+			errorMsg: "unrecognized token at 1:5",
+		},
+		`unclosed object`: {
+			input:    `{`,
+			errorMsg: "expected string literal or '}' after '{', found end of input at 1:2",
+		},
+		`empty input`: {
+			input:    ``,
+			errorMsg: "expected value, found end of input at 1:1",
 		},
 		`input is not json`: {
 			input:    `dasdasdsa`,
-			errorMsg: "unrecognized token at position 0",
+			errorMsg: "unrecognized token at 1:1",
 		},
 	}
 
@@ -211,3 +234,689 @@ func TestErrorHandling(t *testing.T) {
 	}
 
 }
+
+func TestStringEscapes(t *testing.T) {
+	var stringCandidates = map[string]string{
+		`"say \"hi\""`:       `say "hi"`,
+		`"back\\slash"`:      `back\slash`,
+		`"a\/b"`:             `a/b`,
+		`"back\bspace"`:      "back\bspace",
+		`"form\ffeed"`:       "form\ffeed",
+		`"new\nline"`:        "new\nline",
+		`"carriage\rreturn"`: "carriage\rreturn",
+		`"tab\tulated"`:      "tab\tulated",
+		`"\\\""`:             `\"`,
+		`"ends with \\"`:     `ends with \`,
+		`"\"\\\/\b\f\n\r\t"`: "\"\\/\b\f\n\r\t",
+	}
+
+	for inputJson, expected := range stringCandidates {
+		name := fmt.Sprintf("strings(%s)", inputJson)
+		t.Run(name, func(t *testing.T) {
+			json, err := Parse(inputJson)
+			if err != nil {
+				t.Fatalf("%s", err.Error())
+			}
+
+			if !reflect.DeepEqual(json, JsonValue{ValueType: STRING, Value: expected}) {
+				t.Fatalf("expected: %q, got: %q", expected, json.Value)
+			}
+		})
+	}
+
+	t.Run("escaped key in object", func(t *testing.T) {
+		json, err := Parse(`{"a\"b": "c\\d"}`)
+		if err != nil {
+			t.Fatalf("%s", err.Error())
+		}
+		expected := JsonValue{
+			ValueType: OBJECT,
+			Value: map[string]JsonValue{
+				`a"b`: {ValueType: STRING, Value: `c\d`},
+			},
+		}
+		if !reflect.DeepEqual(json, expected) {
+			t.Fail()
+		}
+	})
+}
+
+func TestStringErrors(t *testing.T) {
+	var testCases = map[string]struct {
+		input, errorMsg string
+	}{
+		`unknown escape`: {
+			input:    `"\x41"`,
+			errorMsg: `invalid escape sequence: \x at 1:2`,
+		},
+		`raw newline`: {
+			input:    "\"new\nline\"",
+			errorMsg: "control characters must be escaped in strings at 1:5",
+		},
+		`raw tab`: {
+			input:    "[\"\t\"]",
+			errorMsg: "control characters must be escaped in strings at 1:3",
+		},
+		`escaped closing quote`: {
+			input:    `"abc\"`,
+			errorMsg: "string is not properly closed at 1:7",
+		},
+		`backslash at the end of input`: {
+			input:    `"abc\`,
+			errorMsg: "string is not properly closed at 1:6",
+		},
+		`short unicode escape`: {
+			input:    `"\u12"`,
+			errorMsg: "invalid unicode escape sequence at 1:2",
+		},
+		`non-hex unicode escape`: {
+			input:    `"\u12G4"`,
+			errorMsg: "invalid unicode escape sequence at 1:2",
+		},
+		`bare quote`: {
+			input:    `"`,
+			errorMsg: "string is not properly closed at 1:2",
+		},
+	}
+
+	for name, data := range testCases {
+		t.Run(fmt.Sprintf("string errors: %s", name), func(t *testing.T) {
+			if _, err := Parse(data.input); err == nil {
+				t.Errorf("error value was required")
+			} else if err.Error() != data.errorMsg {
+				t.Errorf("expected: %s, got: %s", data.errorMsg, err.Error())
+			}
+		})
+	}
+}
+
+func TestUnicodeEscapes(t *testing.T) {
+	var stringCandidates = map[string]string{
+		`"\u0041"`:                   "A",
+		`"caf\u00e9"`:                "café",
+		`"\u4f60\u597d"`:             "你好",
+		`"\ud83d\ude00"`:             "😀",
+		`"smile: \uD83D\uDE00!"`:     "smile: 😀!",
+		`"\u0000"`:                   "\x00",
+		`"\u00e9\ud83d\ude00\u00E9"`: "é😀é",
+	}
+
+	for inputJson, expected := range stringCandidates {
+		t.Run(fmt.Sprintf("unicode(%s)", inputJson), func(t *testing.T) {
+			json, err := Parse(inputJson)
+			if err != nil {
+				t.Fatalf("%s", err.Error())
+			}
+			if json.Value != expected {
+				t.Fatalf("expected: %q, got: %q", expected, json.Value)
+			}
+		})
+	}
+
+	var surrogateCandidates = []struct {
+		input    string
+		policy   SurrogatePolicy
+		expected string
+		errorMsg string
+	}{
+		{`"\ud800"`, SurrogateError, "", `lone surrogate in unicode escape: \ud800 at 1:2`},
+		{`"a\udc00b"`, SurrogateError, "", `lone surrogate in unicode escape: \udc00 at 1:3`},
+		{`"\ud800A"`, SurrogateError, "", `lone surrogate in unicode escape: \ud800 at 1:2`},
+		{`"\ud800"`, SurrogateReplace, "�", ""},
+		{`"\ud800A"`, SurrogateReplace, "�A", ""},
+		{`"\ude00\ud83d"`, SurrogateReplace, "��", ""},
+		{`"\ud800"`, SurrogateWTF8, "\xed\xa0\x80", ""},
+		{`"\udfff!"`, SurrogateWTF8, "\xed\xbf\xbf!", ""},
+		{`"\ud83d\ude00"`, SurrogateWTF8, "😀", ""},
+	}
+
+	for _, data := range surrogateCandidates {
+		t.Run(fmt.Sprintf("surrogates(%s, %d)", data.input, data.policy), func(t *testing.T) {
+			json, err := ParseWithOptions(data.input, ParseOptions{LoneSurrogates: data.policy})
+			if data.errorMsg != "" {
+				if err == nil || err.Error() != data.errorMsg {
+					t.Fatalf("expected: %s, got: %v", data.errorMsg, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("%s", err.Error())
+			}
+			if json.Value != data.expected {
+				t.Fatalf("expected: %q, got: %q", data.expected, json.Value)
+			}
+		})
+	}
+}
+
+func TestUTF8Validation(t *testing.T) {
+	var testCases = []struct {
+		input    string
+		opts     ParseOptions
+		expected string
+		errorMsg string
+	}{
+		{"\"caf\xc3\xa9\"", ParseOptions{InvalidUTF8: UTF8Reject}, "café", ""},
+		{"\"a\xffb\"", ParseOptions{}, "a\xffb", ""},
+		{"\"a\xffb\"", ParseOptions{InvalidUTF8: UTF8Reject}, "", "invalid UTF-8 byte sequence in string at 1:3"},
+		{"[\"ok\", \"\xc3\"]", ParseOptions{InvalidUTF8: UTF8Reject}, "", "invalid UTF-8 byte sequence in string at 1:9"},
+		{"\"\xed\xa0\x80\"", ParseOptions{InvalidUTF8: UTF8Reject}, "", "invalid UTF-8 byte sequence in string at 1:2"},
+		{"\"a\xffb\"", ParseOptions{InvalidUTF8: UTF8Replace}, "a�b", ""},
+		{"\"\xe2\x82\"", ParseOptions{InvalidUTF8: UTF8Replace}, "��", ""},
+		{"\xef\xbb\xbf\"bom\"", ParseOptions{}, "", "input starts with a byte order mark at 1:1"},
+		{"\xef\xbb\xbf\"bom\"", ParseOptions{AllowBOM: true}, "bom", ""},
+		{"\xef\xbb\xbf\"\xff\"", ParseOptions{AllowBOM: true, InvalidUTF8: UTF8Reject}, "", "invalid UTF-8 byte sequence in string at 1:3"},
+	}
+
+	for _, data := range testCases {
+		t.Run(fmt.Sprintf("utf8(%q)", data.input), func(t *testing.T) {
+			json, err := ParseWithOptions(data.input, data.opts)
+			if data.errorMsg != "" {
+				if err == nil || err.Error() != data.errorMsg {
+					t.Fatalf("expected: %s, got: %v", data.errorMsg, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("%s", err.Error())
+			}
+			if json.Value != data.expected {
+				t.Fatalf("expected: %q, got: %q", data.expected, json.Value)
+			}
+		})
+	}
+}
+
+func FuzzParse(f *testing.F) {
+	var seeds = []string{
+		`{"value": [1239, 123.45], "name": "renault", "token": true, "hello": null}`,
+		`[1, 2, 3]`,
+		`"a\"bé😀"`,
+		`-0.000123`,
+		`3.14e-3`,
+		`[tr`,
+		`n`,
+		`"`,
+		`{`,
+		`[`,
+		``,
+	}
+	for _, seed := range seeds {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, input string) {
+		// the parser must never panic, whatever the input is
+		_, _ = Parse(input)
+	})
+}
+
+func TestStrictNumbers(t *testing.T) {
+	var numberCandidates = map[string]float64{
+		"-0":      0,
+		"0.5":     0.5,
+		"1E5":     100000,
+		"1e+2":    100,
+		"1E-2":    0.01,
+		"1e007":   1e7,
+		"-12.5e1": -125,
+		"0e0":     0,
+	}
+
+	for inputJson, expected := range numberCandidates {
+		t.Run(fmt.Sprintf("strict numbers(%s)", inputJson), func(t *testing.T) {
+			json, err := Parse(inputJson)
+			if err != nil {
+				t.Fatalf("%s", err.Error())
+			}
+			if !reflect.DeepEqual(json, JsonValue{ValueType: NUMBER, Value: expected}) {
+				t.Fatalf("expected: %v, got: %v", expected, json.Value)
+			}
+		})
+	}
+
+	var testCases = map[string]struct {
+		input, errorMsg string
+	}{
+		`leading plus`:           {`+1`, "numbers cannot start with a plus sign at 1:1"},
+		`leading zero`:           {`0123`, "numbers cannot have leading zeros at 1:1"},
+		`negative leading zero`:  {`[-01]`, "numbers cannot have leading zeros at 1:3"},
+		`trailing decimal point`: {`1.`, "expected digit after decimal point at 1:3"},
+		`decimal point exponent`: {`1.e5`, "expected digit after decimal point at 1:3"},
+		`leading decimal point`:  {`.5`, "numbers cannot start with a decimal point at 1:1"},
+		`lone minus`:             {`[-]`, "expected digit in number at 1:3"},
+		`minus with whitespace`:  {`- 1`, "expected digit in number at 1:2"},
+		`empty exponent`:         {`1e`, "expected digit in exponent at 1:3"},
+		`signed empty exponent`:  {`1e+`, "expected digit in exponent at 1:4"},
+		`standalone exponent`:    {`[e]`, "unrecognized token at 1:2"},
+	}
+
+	for name, data := range testCases {
+		t.Run(fmt.Sprintf("strict numbers: %s", name), func(t *testing.T) {
+			if _, err := Parse(data.input); err == nil {
+				t.Errorf("error value was required")
+			} else if err.Error() != data.errorMsg {
+				t.Errorf("expected: %s, got: %s", data.errorMsg, err.Error())
+			}
+		})
+	}
+
+	var lenientCandidates = map[string]float64{
+		"+1":    1,
+		"0123":  123,
+		"-007":  -7,
+		"+1e+1": 10,
+	}
+
+	for inputJson, expected := range lenientCandidates {
+		t.Run(fmt.Sprintf("lenient numbers(%s)", inputJson), func(t *testing.T) {
+			json, err := ParseWithOptions(inputJson, ParseOptions{LenientNumbers: true})
+			if err != nil {
+				t.Fatalf("%s", err.Error())
+			}
+			if !reflect.DeepEqual(json, JsonValue{ValueType: NUMBER, Value: expected}) {
+				t.Fatalf("expected: %v, got: %v", expected, json.Value)
+			}
+		})
+	}
+}
+
+func TestNumberOverflow(t *testing.T) {
+	t.Run("out of range numbers are rejected by default", func(t *testing.T) {
+		var testCases = map[string]string{
+			`1e400`:             "number out of range: 1e400 at 1:1",
+			`[1, -1.5E+400]`:    "number out of range: -1.5E+400 at 1:5",
+			`{"big": 2e308}`:    "number out of range: 2e308 at 1:9",
+			`{"a": [0, 1e999]}`: "number out of range: 1e999 at 1:11",
+		}
+		for input, errorMsg := range testCases {
+			if _, err := Parse(input); err == nil || err.Error() != errorMsg {
+				t.Errorf("expected: %s, got: %v", errorMsg, err)
+			}
+		}
+	})
+
+	t.Run("underflow is not an error", func(t *testing.T) {
+		json, err := Parse(`1e-400`)
+		if err != nil {
+			t.Fatalf("%s", err.Error())
+		}
+		if json.Value != float64(0) {
+			t.Fatalf("expected: 0, got: %v", json.Value)
+		}
+	})
+
+	t.Run("out of range numbers can be clamped", func(t *testing.T) {
+		json, err := ParseWithOptions(`[1e400, -1e400]`, ParseOptions{NumberOverflow: OverflowClamp})
+		if err != nil {
+			t.Fatalf("%s", err.Error())
+		}
+		expected := JsonValue{ValueType: ARRAY, Value: []JsonValue{
+			{ValueType: NUMBER, Value: math.Inf(1)},
+			{ValueType: NUMBER, Value: math.Inf(-1)},
+		}}
+		if !reflect.DeepEqual(json, expected) {
+			t.Fatalf("expected: %v, got: %v", expected, json)
+		}
+	})
+
+	t.Run("out of range numbers can be kept exact", func(t *testing.T) {
+		json, err := ParseWithOptions(`[1.5, -12.5E+400]`, ParseOptions{NumberOverflow: OverflowExact})
+		if err != nil {
+			t.Fatalf("%s", err.Error())
+		}
+		expected := JsonValue{ValueType: ARRAY, Value: []JsonValue{
+			{ValueType: NUMBER, Value: 1.5},
+			{ValueType: NUMBER, Value: JsonNumber("-12.5E+400")},
+		}}
+		if !reflect.DeepEqual(json, expected) {
+			t.Fatalf("expected: %v, got: %v", expected, json)
+		}
+	})
+}
+
+func TestErrorLocation(t *testing.T) {
+	input := "{\n  \"name\": \"renault\",\n\t\"hello\": nill\n}"
+	_, err := Parse(input)
+	if err == nil {
+		t.Fatalf("error value was required")
+	}
+
+	if err.Offset != 33 || err.Line != 3 || err.Column != 11 {
+		t.Errorf("unexpected location: offset %d, line %d, column %d", err.Offset, err.Line, err.Column)
+	}
+	if err.Pos != err.Offset {
+		t.Errorf("expected the deprecated Pos to match Offset, got: %d", err.Pos)
+	}
+	if err.Error() != "unrecognized token at 3:11" {
+		t.Errorf("unexpected message: %s", err.Error())
+	}
+
+	expectedSnippet := "3 | \t\"hello\": nill\n  | \t         ^"
+	if err.Snippet() != expectedSnippet {
+		t.Errorf("expected snippet:\n%s\ngot:\n%s", expectedSnippet, err.Snippet())
+	}
+
+	var testCases = map[string]struct {
+		input, errorMsg string
+	}{
+		`crlf line endings`:            {"[\r\n1,\r\n  x]", "unrecognized token at 3:3"},
+		`columns count characters`:     {`["héllo", nul]`, "unrecognized token at 1:11"},
+		`top-level comma`:              {`1, 2`, "expected end of input after the top-level value, found ',' at 1:2"},
+		`data after top-level value`:   {`[1] [2]`, "expected end of input after the top-level value, found '[' at 1:5"},
+		`unclosed array at the end`:    {"[1,\n 2", "expected ',' or ']' after array element, found end of input at 2:3"},
+		`out of range number position`: {"{\n\"a\": 1e999}", "number out of range: 1e999 at 2:6"},
+	}
+
+	for name, data := range testCases {
+		t.Run(fmt.Sprintf("error location: %s", name), func(t *testing.T) {
+			if _, err := Parse(data.input); err == nil {
+				t.Errorf("error value was required")
+			} else if err.Error() != data.errorMsg {
+				t.Errorf("expected: %s, got: %s", data.errorMsg, err.Error())
+			}
+		})
+	}
+
+	t.Run("crlf is stripped from the snippet", func(t *testing.T) {
+		_, err := Parse("[1,\r\n x\r\n]")
+		if err == nil || err.Snippet() != "2 |  x\n  |  ^" {
+			t.Errorf("unexpected snippet: %q", err.Snippet())
+		}
+	})
+
+	t.Run("long lines are cut around the error", func(t *testing.T) {
+		_, err := Parse("[" + strings.Repeat("1,", 1000) + "x" + strings.Repeat(",1", 1000) + "]")
+		expected := "1 | …" + strings.Repeat("1,", 20) + "x" + strings.Repeat(",1", 19) + ",…\n" +
+			"  | " + strings.Repeat(" ", 41) + "^"
+		if err == nil || err.Column != 2002 || err.Snippet() != expected {
+			t.Errorf("expected snippet:\n%s\ngot:\n%s", expected, err.Snippet())
+		}
+	})
+}
+
+func TestExpectedTokens(t *testing.T) {
+	input := "{\n  \"name\": \"gojson\",\n  \"tags\": [\"a\", \"b\"],\n  \"version\": 10 \"license\": \"MIT\"\n}"
+	if _, err := Parse(input); err == nil {
+		t.Errorf("error value was required")
+	} else if err.Error() != "expected ',' or '}' after object member, found string literal at 4:17" {
+		t.Errorf("unexpected message: %s", err.Error())
+	}
+
+	var testCases = map[string]struct {
+		input, errorMsg string
+	}{
+		`missing colon`:            {`{"a" 1}`, "expected ':' after object key, found number at 1:6"},
+		`non-string key`:           {`{1: 2}`, "expected string literal or '}' after '{', found number at 1:2"},
+		`missing value`:            {`{"a": }`, "expected value after ':', found '}' at 1:7"},
+		`trailing comma in array`:  {`[1, 2,]`, "expected value after ',', found ']' at 1:7"},
+		`trailing comma in object`: {`{"a": 1,}`, "expected string literal after ',', found '}' at 1:9"},
+		`missing comma in array`:   {`[true null]`, "expected ',' or ']' after array element, found null at 1:7"},
+		`unopened close`:           {`]`, "expected value, found ']' at 1:1"},
+		`colon in array`:           {`["a": 1]`, "expected ',' or ']' after array element, found ':' at 1:5"},
+		`unclosed nested`:          {`{"a": [{"b": false}`, "expected ',' or ']' after array element, found end of input at 1:20"},
+	}
+
+	for name, data := range testCases {
+		t.Run(fmt.Sprintf("expected tokens: %s", name), func(t *testing.T) {
+			if _, err := Parse(data.input); err == nil {
+				t.Errorf("error value was required")
+			} else if err.Error() != data.errorMsg {
+				t.Errorf("expected: %s, got: %s", data.errorMsg, err.Error())
+			}
+		})
+	}
+}
+
+func TestParseAll(t *testing.T) {
+	t.Run("valid input has no errors", func(t *testing.T) {
+		json, errs := ParseAll(`{"a": [1, "b", null]}`)
+		if errs != nil {
+			t.Fatalf("unexpected errors: %v", errs)
+		}
+		expected, _ := Parse(`{"a": [1, "b", null]}`)
+		if !reflect.DeepEqual(json, expected) {
+			t.Fatalf("expected: %v, got: %v", expected, json)
+		}
+	})
+
+	t.Run("every error is reported", func(t *testing.T) {
+		input := "{\n  \"hello\": nill,\n  \"big\": 1e999,\n  \"list\": [1, 2,],\n  \"ok\": true,\n  \"key\" false\n}"
+		json, errs := ParseAll(input)
+
+		var messages []string
+		for _, err := range errs {
+			messages = append(messages, err.Error())
+		}
+		expectedMessages := []string{
+			"unrecognized token at 2:12",
+			"number out of range: 1e999 at 3:10",
+			"expected value after ',', found ']' at 4:17",
+			"expected ':' after object key, found boolean at 6:9",
+		}
+		if !reflect.DeepEqual(messages, expectedMessages) {
+			t.Fatalf("expected: %v, got: %v", expectedMessages, messages)
+		}
+
+		fields := json.Value.(map[string]JsonValue)
+		if len(fields) != 4 {
+			t.Fatalf("expected 4 fields, got: %v", fields)
+		}
+		if fields["hello"].ValueType != INVALID || fields["hello"].Value != errs[0] {
+			t.Errorf("expected an invalid node carrying the error, got: %v", fields["hello"])
+		}
+		if fields["big"].ValueType != INVALID || fields["big"].Value != errs[1] {
+			t.Errorf("expected an invalid node carrying the error, got: %v", fields["big"])
+		}
+		list := fields["list"].Value.([]JsonValue)
+		if len(list) != 3 || list[0].Value != float64(1) || list[2].ValueType != INVALID {
+			t.Errorf("unexpected list: %v", list)
+		}
+		if fields["ok"].Value != true {
+			t.Errorf("unexpected value: %v", fields["ok"])
+		}
+	})
+
+	var testCases = map[string]struct {
+		input    string
+		expected JsonValue
+		errors   int
+	}{
+		`missing comma`: {`[1 2]`, JsonValue{ValueType: ARRAY, Value: []JsonValue{
+			{ValueType: NUMBER, Value: float64(1)},
+			{ValueType: INVALID},
+		}}, 1},
+		`missing commas`: {`[1 2 3, 4]`, JsonValue{ValueType: ARRAY, Value: []JsonValue{
+			{ValueType: NUMBER, Value: float64(1)},
+			{ValueType: INVALID},
+			{ValueType: NUMBER, Value: float64(4)},
+		}}, 1},
+		`missing comma between fields`: {`{"a": 1 "b": 2, "c": 3}`, JsonValue{ValueType: OBJECT, Value: map[string]JsonValue{
+			"a": {ValueType: NUMBER, Value: float64(1)},
+			"c": {ValueType: NUMBER, Value: float64(3)},
+		}}, 1},
+		`trailing brace`: {`{"a":[1,2]}}`, JsonValue{ValueType: OBJECT, Value: map[string]JsonValue{
+			"a": {ValueType: ARRAY, Value: []JsonValue{{ValueType: NUMBER, Value: float64(1)}, {ValueType: NUMBER, Value: float64(2)}}},
+		}}, 1},
+		`trailing bracket`: {`[1,2]]`, JsonValue{ValueType: ARRAY, Value: []JsonValue{
+			{ValueType: NUMBER, Value: float64(1)},
+			{ValueType: NUMBER, Value: float64(2)},
+		}}, 1},
+		`second top-level value`: {`{"a":1} {"b":2}`, JsonValue{ValueType: OBJECT, Value: map[string]JsonValue{
+			"a": {ValueType: NUMBER, Value: float64(1)},
+		}}, 1},
+		`trailing garbage`: {`[1] ] nill }`, JsonValue{ValueType: ARRAY, Value: []JsonValue{
+			{ValueType: NUMBER, Value: float64(1)},
+		}}, 2},
+		`unclosed containers`: {`{"a": [1`, JsonValue{ValueType: OBJECT, Value: map[string]JsonValue{
+			"a": {ValueType: ARRAY, Value: []JsonValue{{ValueType: NUMBER, Value: float64(1)}}},
+		}}, 1},
+		`mismatched bracket`: {`{"a": [true}`, JsonValue{ValueType: OBJECT, Value: map[string]JsonValue{
+			"a": {ValueType: ARRAY, Value: []JsonValue{{ValueType: BOOL, Value: true}}},
+		}}, 1},
+		`malformed key`: {`{nill: 1, "b": null}`, JsonValue{ValueType: OBJECT, Value: map[string]JsonValue{
+			"b": {ValueType: NULL, Value: nil},
+		}}, 1},
+		`trailing value`: {`"a" "b"`, JsonValue{ValueType: STRING, Value: "a"}, 1},
+	}
+
+	for name, data := range testCases {
+		t.Run(fmt.Sprintf("recovery: %s", name), func(t *testing.T) {
+			json, errs := ParseAll(data.input)
+			if len(errs) != data.errors {
+				t.Fatalf("expected %d errors, got: %v", data.errors, errs)
+			}
+			if json = withoutErrors(json); !reflect.DeepEqual(json, data.expected) {
+				t.Fatalf("expected: %v, got: %v", data.expected, json)
+			}
+		})
+	}
+}
+
+// withoutErrors drops the errors the invalid nodes carry, so that the values can be compared
+func withoutErrors(jv JsonValue) JsonValue {
+	switch jv.ValueType {
+	case INVALID:
+		return JsonValue{ValueType: INVALID}
+	case OBJECT:
+		members := map[string]JsonValue{}
+		for key, member := range jv.Value.(map[string]JsonValue) {
+			members[key] = withoutErrors(member)
+		}
+		return JsonValue{ValueType: OBJECT, Value: members}
+	case ARRAY:
+		elements := make([]JsonValue, 0, len(jv.Value.([]JsonValue)))
+		for _, element := range jv.Value.([]JsonValue) {
+			elements = append(elements, withoutErrors(element))
+		}
+		return JsonValue{ValueType: ARRAY, Value: elements}
+	}
+	return jv
+}
+
+func FuzzParseAll(f *testing.F) {
+	var seeds = []string{
+		`{"hello": nill, "a": [1 2], "b": {"c" 1}}`,
+		`[1, 2,]`,
+		`{"a": [1, 2`,
+		`]`,
+		`1, 2`,
+		``,
+	}
+	for _, seed := range seeds {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, input string) {
+		json, errs := ParseAll(input)
+		expected, err := Parse(input)
+		if (err == nil) != (len(errs) == 0) {
+			t.Fatalf("Parse error: %v, ParseAll errors: %v", err, errs)
+		}
+		if err != nil {
+			return
+		}
+		if !reflect.DeepEqual(json, expected) {
+			t.Fatalf("expected: %v, got: %v", expected, json)
+		}
+
+		// a stray token after a valid value leaves the value as it is
+		for _, stray := range []string{"]", "}", ",", " 1"} {
+			json, errs := ParseAll(input + stray)
+			if len(errs) != 1 || !reflect.DeepEqual(json, expected) {
+				t.Fatalf("expected: %v and 1 error, got: %v, %v", expected, json, errs)
+			}
+		}
+	})
+}
+
+func TestDeeplyNestedInput(t *testing.T) {
+	depth := 10000
+	input := strings.Repeat("[", depth) + "1" + strings.Repeat("]", depth)
+
+	json, err := Parse(input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for i := 0; i < depth; i++ {
+		elements, ok := json.Value.([]JsonValue)
+		if !ok || len(elements) != 1 {
+			t.Fatalf("expected an array with one element at depth %d, got: %v", i, json)
+		}
+		json = elements[0]
+	}
+	if json.Value != float64(1) {
+		t.Fatalf("expected: 1, got: %v", json.Value)
+	}
+}
+
+func TestDeeplyNestedRecovery(t *testing.T) {
+	depth := 10000
+	var testCases = map[string]struct {
+		input  string
+		errors int
+		// bottom is the type of the innermost value
+		bottom JsonValueType
+	}{
+		"stray colon in objects":    {strings.Repeat(`{"a":`, depth) + ":", 1, INVALID},
+		"invalid tokens in arrays":  {strings.Repeat("[", depth) + strings.Repeat("x ", 50), 50, INVALID},
+		"stray brackets in objects": {strings.Repeat(`{"a":`, depth) + strings.Repeat("]", depth), 1, INVALID},
+		"missing colons":            {strings.Repeat(`{"a":`, depth) + strings.Repeat(": ]", depth), 1, INVALID},
+		"unclosed arrays":           {strings.Repeat("[", depth) + "1", 1, NUMBER},
+		"stray braces in arrays":    {strings.Repeat("[", depth) + "1" + strings.Repeat(" } 1,", 100), 1, NUMBER},
+	}
+
+	for name, data := range testCases {
+		t.Run(name, func(t *testing.T) {
+			start := time.Now()
+			json, errs := ParseAll(data.input)
+			// recovering used to walk the whole stack for every candidate size of it, which took minutes
+			if elapsed := time.Since(start); elapsed > 5*time.Second {
+				t.Fatalf("recovering took %v", elapsed)
+			}
+			if len(errs) != data.errors {
+				t.Fatalf("expected %d errors, got: %d", data.errors, len(errs))
+			}
+
+			for i := 0; i < depth; i++ {
+				switch v := json.Value.(type) {
+				case []JsonValue:
+					json = v[0]
+				case map[string]JsonValue:
+					json = v["a"]
+				default:
+					t.Fatalf("expected a container at depth %d, got: %v", i, json)
+				}
+			}
+			if json.ValueType != data.bottom {
+				t.Fatalf("expected %s at the bottom, got: %v", data.bottom, json)
+			}
+		})
+	}
+}
+
+func TestRecoveryOnOneLine(t *testing.T) {
+	count := 100000
+	input := "[" + strings.Repeat("x,", count) + "1]"
+
+	start := time.Now()
+	_, errs := ParseAll(input)
+	// every error used to be located from the start of the input, which took minutes on minified input
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("recovering took %v", elapsed)
+	}
+	if len(errs) != count {
+		t.Fatalf("expected %d errors, got: %d", count, len(errs))
+	}
+
+	last := errs[count-1]
+	if last.Line != 1 || last.Column != 2*count {
+		t.Fatalf("unexpected location: line %d, column %d", last.Line, last.Column)
+	}
+	expected := "1 | …" + strings.Repeat("x,", 20) + "x,1]\n" +
+		"  | " + strings.Repeat(" ", 41) + "^"
+	if last.Snippet() != expected {
+		t.Fatalf("expected snippet:\n%s\ngot:\n%s", expected, last.Snippet())
+	}
+}
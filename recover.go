@@ -0,0 +1,206 @@
+package gojson
+
+import "sort"
+
+// ParseAll parses the input like Parse, but does not stop at the first error.
+// Malformed values are replaced by nodes of type INVALID and malformed
+// object fields are left out, so that the rest of the input can still be parsed.
+// It returns the partially parsed json alongside every error found, in the order
+// they appear in the input. A nil slice of errors means the input is valid
+func ParseAll(input string) (JsonValue, []*Error) {
+	return ParseAllWithOptions(input, ParseOptions{})
+}
+
+// ParseAllWithOptions works like ParseAll, but lets the caller
+// configure how the input is interpreted
+func ParseAllWithOptions(input string, opts ParseOptions) (JsonValue, []*Error) {
+	tokens, errs := scan(input, opts, true)
+
+	tokens, syntaxErrs := recognizeAll(tokens, len(input))
+	errs = append(errs, syntaxErrs...)
+
+	// the steps taken over the repaired tokens would only be misleading
+	opts.Tracer = nil
+	json, _ := build(tokens, input, &opts, &errs)
+
+	sort.SliceStable(errs, func(i, j int) bool {
+		return errs[i].Offset < errs[j].Offset
+	})
+	locateAll(errs, input)
+	return json, errs
+}
+
+// isSyncToken checks if parsing can resume at the terminal after an error
+func isSyncToken(terminal elementType) bool {
+	return terminal == ltComma || terminal == ltObjectEnd || terminal == ltArrayEnd
+}
+
+// recognizeAll runs the tokens through the parsing tables like build, but recovers from syntax errors in panic mode:
+// the tokens up to the next ',', '}' or ']' are skipped, and an invalid node takes
+// the place of the malformed value or object field. Anything after a complete value is skipped.
+// It returns the repaired tokens, which always form a valid json text, and the errors found
+func recognizeAll(tokens []token, inputLength int) ([]token, []*Error) {
+	r := newRecognizer()
+	r.keepTokens = true
+	var errs []*Error
+	var lastErr *Error
+	// no more errors are reported until a token is shifted after an error,
+	// otherwise a single mistake could cause a cascade of them
+	quiet := false
+
+	for i := 0; ; {
+		lookahead := tokenAt(tokens, i, inputLength)
+		if r.accepts(lookahead.tokenType) {
+			if done, _ := r.feed(lookahead); done {
+				return r.output, errs
+			}
+			quiet = false
+			i++
+			continue
+		}
+
+		if r.accepts(ltEnd) {
+			// the value is complete, so what comes after it cannot be part of it.
+			// Resyncing would tear the value apart to fit the rest in
+			if lookahead.tokenType != ltInvalid && !quiet {
+				errs = append(errs, r.unexpectedToken(lookahead))
+			}
+			i = len(tokens)
+			continue
+		}
+
+		if lookahead.tokenType == ltInvalid {
+			// the lexer has already reported this one
+			lastErr = lookahead.value.(*Error)
+		} else if !quiet {
+			lastErr = r.unexpectedToken(lookahead)
+			errs = append(errs, lastErr)
+		}
+		quiet = true
+
+		j := i
+		for j < len(tokens) && !isSyncToken(tokens[j].tokenType) {
+			j++
+		}
+		sync := tokenAt(tokens, j, inputLength)
+		invalid := token{value: lastErr, tokenType: ltInvalid, start: lookahead.start, end: lookahead.end}
+
+		if r.resync(sync.tokenType, invalid, j > i) {
+			i = j
+		} else {
+			// there is no way to make use of the sync token, so it is skipped as well
+			i = j + 1
+		}
+	}
+}
+
+// resync brings the recognizer into a state that accepts the sync terminal,
+// by inserting an invalid node, closing objects and arrays or dropping
+// the symbols that cannot be completed. It reports whether it succeeded.
+// If tokens have been skipped where a ',' could come, e.g. the 2 in [1 2, 3],
+// they are taken for an element missing its comma and replaced by an invalid node
+func (r *recognizer) resync(sync elementType, invalid token, skipped bool) bool {
+	if skipped && r.accepts(ltComma) {
+		s := r.simulate(len(r.symbols))
+		if s.feed(ltComma) {
+			terminal, ok := invalidTerminal(s.state())
+			if sync == ltEnd || ok && s.feed(terminal, sync) {
+				_, _ = r.feed(token{tokenType: ltComma, start: invalid.start, end: invalid.start})
+			}
+		}
+	}
+
+	if sync == ltEnd {
+		r.closeAll(invalid)
+		return true
+	}
+
+	for {
+		if r.accepts(sync) || r.shiftInvalid(sync, invalid) {
+			return true
+		}
+
+		closed := false
+		for _, closer := range []elementType{ltObjectEnd, ltArrayEnd} {
+			if r.accepts(closer) {
+				_, _ = r.feed(token{tokenType: closer, start: invalid.start, end: invalid.start})
+				closed = true
+				break
+			}
+		}
+		if closed {
+			continue
+		}
+
+		// an invalid node can only take the place of the symbols dropped
+		// where a value or an object field begins, the other sizes of the stack are not tried.
+		// Neither are the ones that have failed before, so that stray tokens deep in the input
+		// do not walk the whole stack over and over
+		if r.unsyncable == nil {
+			r.unsyncable = map[elementType]int{}
+		}
+		for size := len(r.symbols) - 1; size >= r.unsyncable[sync]; size-- {
+			terminal, ok := invalidTerminal(r.states[size])
+			if ok && r.simulate(size).feed(terminal, sync) {
+				r.truncate(size)
+				return r.shiftInvalid(sync, invalid)
+			}
+		}
+		r.unsyncable[sync] = len(r.symbols)
+		return false
+	}
+}
+
+// closeAll completes the json text when the input ends early: an invalid node takes
+// the place of the missing value or object field, and every open object and array is closed
+func (r *recognizer) closeAll(invalid token) {
+	for !r.accepts(ltEnd) {
+		if terminal, ok := invalidTerminal(r.states[len(r.states)-1]); ok {
+			invalid.tokenType = terminal
+			_, _ = r.feed(invalid)
+			continue
+		}
+
+		closed := false
+		for _, closer := range []elementType{ltObjectEnd, ltArrayEnd} {
+			if r.accepts(closer) {
+				_, _ = r.feed(token{tokenType: closer, start: invalid.start, end: invalid.start})
+				closed = true
+				break
+			}
+		}
+		if closed {
+			continue
+		}
+
+		// e.g. an object key without its colon is dropped, down to where the object field begins
+		size := len(r.symbols) - 1
+		for _, ok := invalidTerminal(r.states[size]); !ok; _, ok = invalidTerminal(r.states[size]) {
+			size--
+		}
+		r.truncate(size)
+	}
+}
+
+// shiftInvalid shifts an invalid value or object field,
+// if the sync terminal can follow it
+func (r *recognizer) shiftInvalid(sync elementType, invalid token) bool {
+	terminal, ok := invalidTerminal(r.states[len(r.states)-1])
+	if !ok || !r.simulate(len(r.symbols)).feed(terminal, sync) {
+		return false
+	}
+	invalid.tokenType = terminal
+	_, _ = r.feed(invalid)
+	return true
+}
+
+// invalidTerminal returns the stand-in for malformed input the state accepts, if any:
+// the states where a value begins accept ltInvalid, and the ones where an object field begins ltInvalidMember
+func invalidTerminal(state int) (elementType, bool) {
+	for _, terminal := range []elementType{ltInvalid, ltInvalidMember} {
+		if _, ok := automaton.actions[state][terminal]; ok {
+			return terminal, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,160 @@
+package gojson
+
+import (
+	"bytes"
+	"io"
+	"unicode/utf8"
+)
+
+// streamChunkSize is the minimum number of bytes read from a stream at once
+const streamChunkSize = 4096
+
+// streamBuffer reads a stream into a buffer on demand. It keeps track of
+// where the buffer starts in the stream, so that errors found in the buffer
+// can be located in the whole stream
+type streamBuffer struct {
+	reader io.Reader
+	// buf holds the data that has been read, but not consumed yet. Consuming moves its start forward
+	// within mem, the data is only moved back to the start of mem when more room is needed
+	buf []byte
+	mem []byte
+	eof bool
+	err error // the error reading the stream, other than io.EOF
+
+	offset int
+	line   int
+	column int
+}
+
+func newStreamBuffer(r io.Reader) *streamBuffer {
+	return &streamBuffer{reader: r, line: 1, column: 1}
+}
+
+// fill reads more data from the stream into the buffer.
+// It reports whether any data was read
+func (s *streamBuffer) fill() bool {
+	if s.eof {
+		return false
+	}
+
+	if cap(s.buf)-len(s.buf) < streamChunkSize {
+		if len(s.buf)+streamChunkSize <= cap(s.mem)/2 {
+			// the consumed data leaves enough room at the start, which is reused.
+			// The data moved is at most half of what has been consumed since the last move
+			s.buf = s.mem[:copy(s.mem, s.buf)]
+		} else {
+			// grow geometrically, so that large values are not copied over and over
+			s.mem = make([]byte, 2*cap(s.mem)+streamChunkSize)
+			s.buf = s.mem[:copy(s.mem, s.buf)]
+		}
+	}
+
+	for {
+		n, err := s.reader.Read(s.buf[len(s.buf):cap(s.buf)])
+		s.buf = s.buf[:len(s.buf)+n]
+		if err == io.EOF {
+			s.eof = true
+		} else if err != nil {
+			s.eof = true
+			s.err = err
+		}
+		if n > 0 || s.eof {
+			return n > 0
+		}
+	}
+}
+
+// peek returns the byte at i, reading from the stream until the buffer is long enough.
+// It reports false if the stream ends before that
+func (s *streamBuffer) peek(i int) (uint8, bool) {
+	for i >= len(s.buf) {
+		if !s.fill() {
+			return 0, false
+		}
+	}
+	return s.buf[i], true
+}
+
+// hasByteOrderMark checks if the buffer starts with a byte order mark
+func (s *streamBuffer) hasByteOrderMark() bool {
+	for len(s.buf) < len(byteOrderMark) && s.fill() {
+	}
+	return bytes.HasPrefix(s.buf, []byte(byteOrderMark))
+}
+
+// skipWhitespace returns the position of the first non-whitespace byte from i on
+func (s *streamBuffer) skipWhitespace(i int) int {
+	for {
+		ch, ok := s.peek(i)
+		if !ok || !isWhitespace(ch) {
+			return i
+		}
+		i++
+	}
+}
+
+// endOfString finds where the string starting at i is closed,
+// or where the stream ends if it is not
+func (s *streamBuffer) endOfString(i int) int {
+	for i++; ; i++ {
+		ch, ok := s.peek(i)
+		if !ok {
+			return i
+		}
+		if ch == '\\' {
+			i++
+		} else if ch == '"' {
+			return i + 1
+		}
+	}
+}
+
+// endOfLiteral finds the delimiter that ends the literal starting at i
+func (s *streamBuffer) endOfLiteral(i int) int {
+	for {
+		i++
+		if ch, ok := s.peek(i); !ok || isDelimiter(ch) {
+			return i
+		}
+	}
+}
+
+// isDelimiter checks if the character ends the literal before it
+func isDelimiter(ch uint8) bool {
+	_, special := specialSymbols[ch]
+	return special || isWhitespace(ch) || ch == '"'
+}
+
+// consume drops the first n bytes of the buffer, keeping track of
+// the location of the rest of it in the stream
+func (s *streamBuffer) consume(n int) {
+	consumed := s.buf[:n]
+	if lastLine := bytes.LastIndexByte(consumed, '\n'); lastLine != -1 {
+		s.line += bytes.Count(consumed, []byte{'\n'})
+		s.column = 1
+		consumed = consumed[lastLine+1:]
+	}
+	s.column += utf8.RuneCount(consumed)
+	s.offset += n
+	s.buf = s.buf[n:]
+}
+
+// locate moves the error from the start of the buffer to where it is in the stream
+func (s *streamBuffer) locate(err *Error) *Error {
+	if err.Offset < 0 {
+		return err
+	}
+	err.Offset += s.offset
+	err.Pos = err.Offset
+	if err.Line == 1 {
+		// the line of the snippet starts where the buffer does
+		err.Column += s.column - 1
+		if err.sourceColumn > 1 {
+			err.sourceColumn += s.column - 1
+		} else {
+			err.sourceColumn = s.column
+		}
+	}
+	err.Line += s.line - 1
+	return err
+}
@@ -0,0 +1,186 @@
+package gojson
+
+import (
+	"bytes"
+	"io"
+	"strings"
+)
+
+type TokenKind = string
+
+const (
+	TokenObjectStart TokenKind = "{"
+	TokenObjectEnd   TokenKind = "}"
+	TokenArrayStart  TokenKind = "["
+	TokenArrayEnd    TokenKind = "]"
+	TokenComma       TokenKind = ","
+	TokenColon       TokenKind = ":"
+	TokenString      TokenKind = STRING
+	TokenNumber      TokenKind = NUMBER
+	TokenBool        TokenKind = BOOL
+	TokenNull        TokenKind = NULL
+)
+
+// Token is a single token of a json text
+type Token struct {
+	Kind TokenKind
+	// Value is the decoded value of the token: a string for TokenString with
+	// the escape sequences resolved, a JsonNumber holding the exact literal for TokenNumber,
+	// a bool for TokenBool and nil for everything else
+	Value interface{}
+	// Start and End are the byte offsets of the first byte and right after
+	// the last byte of the token in the input
+	Start int
+	End   int
+}
+
+// tokenKinds maps the terminals of the grammar to the kinds of tokens they make up
+var tokenKinds = map[elementType]TokenKind{
+	ltObjectStart: TokenObjectStart,
+	ltObjectEnd:   TokenObjectEnd,
+	ltArrayStart:  TokenArrayStart,
+	ltArrayEnd:    TokenArrayEnd,
+	ltComma:       TokenComma,
+	ltColon:       TokenColon,
+	ltString:      TokenString,
+	ltBoolean:     TokenBool,
+	ltNull:        TokenNull,
+}
+
+// Tokenizer splits json text into tokens, reading the input only as far as
+// the token asked for. It does not check if the tokens form a valid json text,
+// e.g. `]]` is split into two tokens without an error
+type Tokenizer struct {
+	stream  *streamBuffer
+	opts    ParseOptions
+	started bool
+	err     error // the first error, every later call returns it as well
+	// lexemes is reused by lexNext, the terminals it returns are only valid until the next call
+	lexemes []token
+}
+
+// NewTokenizer returns a tokenizer over the input string
+func NewTokenizer(input string) *Tokenizer {
+	return NewTokenizerReader(strings.NewReader(input))
+}
+
+// NewTokenizerBytes returns a tokenizer over the input bytes
+func NewTokenizerBytes(input []byte) *Tokenizer {
+	return NewTokenizerReader(bytes.NewReader(input))
+}
+
+// NewTokenizerReader returns a tokenizer reading the input from r.
+// The tokenizer buffers the input, so it may read more data from r than the tokens it has returned
+func NewTokenizerReader(r io.Reader) *Tokenizer {
+	return &Tokenizer{stream: newStreamBuffer(r)}
+}
+
+// SetOptions configures how the tokens are lexed. It has to be called before the first call to Next
+func (t *Tokenizer) SetOptions(opts ParseOptions) {
+	t.opts = opts
+}
+
+// Next returns the next token of the input. It returns io.EOF
+// if there are no more tokens, and an *Error if the input is malformed
+func (t *Tokenizer) Next() (Token, error) {
+	if t.err != nil {
+		return Token{}, t.err
+	}
+
+	token, err := t.next()
+	if err != nil {
+		t.err = err
+		return Token{}, err
+	}
+	return token, nil
+}
+
+func (t *Tokenizer) next() (Token, error) {
+	lexemes, size, err := t.lexNext(0)
+	if err != nil {
+		return Token{}, err
+	}
+
+	token := Token{
+		Kind:  tokenKinds[lexemes[0].tokenType],
+		Start: t.stream.offset,
+		End:   t.stream.offset + size,
+	}
+	switch token.Kind {
+	case TokenString:
+		token.Value = lexemes[0].value
+	case TokenBool:
+		token.Value = lexemes[0].value == "true"
+	case "":
+		token.Kind = TokenNumber
+		token.Value = numberLiteral(lexemes)
+	}
+
+	t.stream.consume(size)
+	return token, nil
+}
+
+// lexNext lexes the token after the whitespace from i on into the terminals of the grammar, without consuming it:
+// the offsets of the terminals are relative to the start of the buffer, and end is where the token ends.
+// Lexing from the start of the buffer consumes the whitespace before the token, which leaves the token
+// at the start of the buffer. It returns io.EOF if there are no more tokens
+func (t *Tokenizer) lexNext(i int) ([]token, int, error) {
+	stream := t.stream
+	if !t.started {
+		t.started = true
+		if stream.hasByteOrderMark() {
+			if !t.opts.AllowBOM {
+				return nil, 0, stream.locate(newError(0, "input starts with a byte order mark").locate(byteOrderMark))
+			}
+			stream.consume(len(byteOrderMark))
+		}
+	}
+
+	if i == 0 {
+		stream.consume(stream.skipWhitespace(0))
+	} else {
+		i = stream.skipWhitespace(i)
+	}
+	ch, ok := stream.peek(i)
+	if !ok {
+		if stream.err != nil {
+			return nil, 0, stream.err
+		}
+		return nil, 0, io.EOF
+	}
+
+	// make sure the whole token is in the buffer before lexing it
+	end := i + 1
+	if _, special := specialSymbols[ch]; !special {
+		if ch == '"' {
+			end = stream.endOfString(i)
+		} else {
+			end = stream.endOfLiteral(i)
+		}
+	}
+	if stream.err != nil {
+		return nil, 0, stream.err
+	}
+
+	lexemes, size, err := lexToken(t.lexemes[:0], stream.buf[:end], i, t.opts)
+	if err != nil {
+		return nil, 0, stream.locate(err.locate(string(stream.buf[:end])))
+	}
+	t.lexemes = lexemes
+	return lexemes, i + size, nil
+}
+
+// numberLiteral joins the tokens the lexer splits a number into
+func numberLiteral(lexemes []token) JsonNumber {
+	var sb strings.Builder
+	sb.Grow(lexemes[len(lexemes)-1].end - lexemes[0].start)
+	for _, lexeme := range lexemes {
+		switch v := lexeme.value.(type) {
+		case string:
+			sb.WriteString(v)
+		case uint8:
+			sb.WriteByte(v)
+		}
+	}
+	return JsonNumber(sb.String())
+}
@@ -0,0 +1,189 @@
+package gojson
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func tokenizeAll(t *Tokenizer) ([]Token, error) {
+	var tokens []Token
+	for {
+		token, err := t.Next()
+		if err != nil {
+			return tokens, err
+		}
+		tokens = append(tokens, token)
+	}
+}
+
+func TestTokenizer(t *testing.T) {
+	input := `{"a\n": [-1.5e+3, true, null]} ]false`
+	expected := []Token{
+		{TokenObjectStart, nil, 0, 1},
+		{TokenString, "a\n", 1, 6},
+		{TokenColon, nil, 6, 7},
+		{TokenArrayStart, nil, 8, 9},
+		{TokenNumber, JsonNumber("-1.5e+3"), 9, 16},
+		{TokenComma, nil, 16, 17},
+		{TokenBool, true, 18, 22},
+		{TokenComma, nil, 22, 23},
+		{TokenNull, nil, 24, 28},
+		{TokenArrayEnd, nil, 28, 29},
+		{TokenObjectEnd, nil, 29, 30},
+		{TokenArrayEnd, nil, 31, 32},
+		{TokenBool, false, 32, 37},
+	}
+
+	var tokenizers = map[string]*Tokenizer{
+		"string":       NewTokenizer(input),
+		"bytes":        NewTokenizerBytes([]byte(input)),
+		"reader":       NewTokenizerReader(strings.NewReader(input)),
+		"byte by byte": NewTokenizerReader(iotest.OneByteReader(strings.NewReader(input))),
+	}
+	for name, tokenizer := range tokenizers {
+		t.Run(name, func(t *testing.T) {
+			tokens, err := tokenizeAll(tokenizer)
+			if err != io.EOF {
+				t.Fatalf("expected io.EOF, got: %v", err)
+			}
+			if !reflect.DeepEqual(tokens, expected) {
+				t.Fatalf("expected: %v, got: %v", expected, tokens)
+			}
+		})
+	}
+}
+
+func TestTokenizerOptions(t *testing.T) {
+	tokenizer := NewTokenizer(byteOrderMark + `+01`)
+	tokenizer.SetOptions(ParseOptions{AllowBOM: true, LenientNumbers: true})
+
+	tokens, err := tokenizeAll(tokenizer)
+	if err != io.EOF {
+		t.Fatalf("expected io.EOF, got: %v", err)
+	}
+	expected := []Token{{TokenNumber, JsonNumber("01"), 3, 6}}
+	if !reflect.DeepEqual(tokens, expected) {
+		t.Fatalf("expected: %v, got: %v", expected, tokens)
+	}
+}
+
+func TestTokenizerErrors(t *testing.T) {
+	var data = map[string]struct {
+		input    string
+		expected string
+	}{
+		`unclosed string`:  {"[\"abc", "string is not properly closed at 1:6"},
+		`invalid literal`:  {"[1,\n  nul]", "unrecognized token at 2:3"},
+		`leading zeros`:    {"01", "numbers cannot have leading zeros at 1:1"},
+		`byte order mark`:  {byteOrderMark + "1", "input starts with a byte order mark at 1:1"},
+		`bad escape`:       {`["a", "\x"]`, "invalid escape sequence: \\x at 1:8"},
+		`number then junk`: {`[12abc]`, "unrecognized token at 1:4"},
+	}
+
+	for name, data := range data {
+		t.Run(name, func(t *testing.T) {
+			tokenizer := NewTokenizerReader(iotest.OneByteReader(strings.NewReader(data.input)))
+			_, err := tokenizeAll(tokenizer)
+
+			var e *Error
+			if !errors.As(err, &e) {
+				t.Fatalf("expected a syntax error, got: %v", err)
+			}
+			if e.Error() != data.expected {
+				t.Fatalf("expected: %s, got: %s", data.expected, e.Error())
+			}
+			if _, again := tokenizer.Next(); again != err {
+				t.Fatalf("expected the same error again, got: %v", again)
+			}
+		})
+	}
+}
+
+// the tokens of valid input are the ones the parser works with
+func FuzzTokenizer(f *testing.F) {
+	var seeds = []string{
+		`{"a": [1, -2.5E-3, "é"]}`,
+		`[true, false, null]`,
+		`"\"\\"`,
+	}
+	for _, seed := range seeds {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, input string) {
+		lexemes, lerr := lex(input, ParseOptions{})
+		tokens, err := tokenizeAll(NewTokenizerReader(iotest.HalfReader(strings.NewReader(input))))
+		if (lerr == nil) != (err == io.EOF) {
+			t.Fatalf("lex error: %v, tokenizer error: %v", lerr, err)
+		}
+		if lerr != nil {
+			return
+		}
+
+		i := 0
+		for _, token := range tokens {
+			if lexemes[i].start != token.Start {
+				t.Fatalf("expected %v to start at %d", token, lexemes[i].start)
+			}
+			for i < len(lexemes) && lexemes[i].end <= token.End {
+				i++
+			}
+		}
+		if i != len(lexemes) {
+			t.Fatalf("expected %d lexemes to be covered, got: %d", len(lexemes), i)
+		}
+	})
+}
+
+// benchmarkDocument builds a document of about 1.4 MB with every kind of token
+func benchmarkDocument() []byte {
+	var sb strings.Builder
+	sb.WriteString("[\n")
+	for i := 0; i < 10000; i++ {
+		if i > 0 {
+			sb.WriteString(",\n")
+		}
+		fmt.Fprintf(&sb, `  {"id": %d, "name": "item \"%d\"", "price": %d.%02de-1, "tags": ["a", "bé", "c"], "active": %t, "parent": null, "description": "a somewhat longer string to make the document more realistic"}`,
+			i, i, i*7, i%100, i%2 == 0)
+	}
+	sb.WriteString("\n]")
+	return []byte(sb.String())
+}
+
+func BenchmarkTokenizer(b *testing.B) {
+	input := benchmarkDocument()
+	b.SetBytes(int64(len(input)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		tokenizer := NewTokenizerBytes(input)
+		for {
+			if _, err := tokenizer.Next(); err != nil {
+				if err != io.EOF {
+					b.Fatalf("unexpected error: %v", err)
+				}
+				break
+			}
+		}
+	}
+}
+
+func BenchmarkParseEvents(b *testing.B) {
+	input := benchmarkDocument()
+	b.SetBytes(int64(len(input)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if err := ParseEventsReader(bytes.NewReader(input), BaseHandler{}, ParseOptions{}); err != nil {
+			b.Fatalf("unexpected error: %v", err)
+		}
+	}
+}
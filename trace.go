@@ -0,0 +1,90 @@
+package gojson
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+)
+
+type TraceAction = string
+
+const (
+	TraceShift  TraceAction = "shift"
+	TraceReduce TraceAction = "reduce"
+	TraceAccept TraceAction = "accept"
+	TraceReject TraceAction = "reject"
+)
+
+// TraceStep describes a single step of the shift-reduce parser
+type TraceStep struct {
+	Action TraceAction
+	// Stack holds the symbols on the parser stack before the step, the top being the last one
+	Stack []string
+	// Lookahead is the terminal the parser decides the step by
+	Lookahead string
+	// Text is the part of the input the lookahead was lexed from
+	Text string
+	// Rule and Production are the rule and its symbols reduced by, when the action is TraceReduce
+	Rule       string
+	Production []string
+	// Err is the syntax error, when the action is TraceReject
+	Err *Error
+}
+
+// Tracer receives the steps the parser takes, see ParseOptions.Tracer
+type Tracer interface {
+	Trace(step TraceStep)
+}
+
+// StepTable is a Tracer which collects the steps into a table
+// of the stack, the lookahead and the action taken at each step
+type StepTable struct {
+	Steps []TraceStep
+}
+
+func (t *StepTable) Trace(step TraceStep) {
+	t.Steps = append(t.Steps, step)
+}
+
+// String renders the collected steps as a table with aligned columns
+func (t *StepTable) String() string {
+	var sb strings.Builder
+	w := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "#\tstack\tlookahead\taction")
+	for i, step := range t.Steps {
+		lookahead := step.Lookahead
+		if step.Text != "" {
+			lookahead = step.Text
+		}
+
+		action := step.Action
+		switch step.Action {
+		case TraceReduce:
+			action = fmt.Sprintf("reduce %s ::= %s", step.Rule, strings.Join(step.Production, " "))
+		case TraceReject:
+			action = fmt.Sprintf("reject: %s", step.Err.Msg)
+		}
+
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", i+1, strings.Join(step.Stack, " "), lookahead, action)
+	}
+	w.Flush()
+	return sb.String()
+}
+
+// trace notifies the tracer of the recognizer, if there is one
+func (r *recognizer) trace(action TraceAction, lookahead token, configure func(step *TraceStep)) {
+	if r.tracer == nil {
+		return
+	}
+
+	step := TraceStep{
+		Action:    action,
+		Stack:     append([]string(nil), r.symbols...),
+		Lookahead: lookahead.tokenType,
+		Text:      r.input[lookahead.start-r.inputOffset : lookahead.end-r.inputOffset],
+	}
+	if configure != nil {
+		configure(&step)
+	}
+	r.tracer.Trace(step)
+}
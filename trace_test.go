@@ -0,0 +1,80 @@
+package gojson
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTracer(t *testing.T) {
+	t.Run("every step is reported", func(t *testing.T) {
+		var table StepTable
+		if _, err := ParseWithOptions(`[1]`, ParseOptions{Tracer: &table}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		var actions []string
+		for _, step := range table.Steps {
+			actions = append(actions, step.Action)
+		}
+		expected := []string{
+			TraceShift,  // [
+			TraceShift,  // 1
+			TraceReduce, // <integer> ::= 1
+			TraceReduce, // <number> ::= <integer>
+			TraceReduce, // <value> ::= <number>
+			TraceReduce, // <array element> ::= <value>
+			TraceReduce, // <array elements> ::= <array element>
+			TraceShift,  // ]
+			TraceReduce, // <array> ::= [ <array elements> ]
+			TraceReduce, // <value> ::= <array>
+			TraceAccept,
+		}
+		if !reflect.DeepEqual(actions, expected) {
+			t.Fatalf("expected: %v, got: %v", expected, actions)
+		}
+
+		reduction := table.Steps[2]
+		if reduction.Rule != integer || !reflect.DeepEqual(reduction.Production, []string{ltDigits}) {
+			t.Fatalf("expected a reduction to %s, got: %+v", integer, reduction)
+		}
+		if reduction.Lookahead != ltArrayEnd || reduction.Text != "]" {
+			t.Fatalf("expected ']' as the lookahead, got: %+v", reduction)
+		}
+		if !reflect.DeepEqual(reduction.Stack, []string{ltArrayStart, ltDigits}) {
+			t.Fatalf("unexpected stack: %v", reduction.Stack)
+		}
+	})
+
+	t.Run("the error is reported", func(t *testing.T) {
+		var table StepTable
+		_, err := ParseWithOptions(`[1 2]`, ParseOptions{Tracer: &table})
+		if err == nil {
+			t.Fatalf("expected an error")
+		}
+
+		last := table.Steps[len(table.Steps)-1]
+		if last.Action != TraceReject || last.Err != err || last.Text != "2" {
+			t.Fatalf("expected the rejection of '2', got: %+v", last)
+		}
+	})
+
+	t.Run("the step table", func(t *testing.T) {
+		var table StepTable
+		if _, err := ParseWithOptions(`true`, ParseOptions{Tracer: &table}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		expected := strings.Join([]string{
+			"#  stack           lookahead       action",
+			"1                  true            shift",
+			"2  <bool_literal>  <end of input>  reduce <boolean> ::= <bool_literal>",
+			"3  <boolean>       <end of input>  reduce <value> ::= <boolean>",
+			"4  <value>         <end of input>  accept",
+			"",
+		}, "\n")
+		if table.String() != expected {
+			t.Fatalf("expected:\n%s\ngot:\n%s", expected, table.String())
+		}
+	})
+}
@@ -0,0 +1,135 @@
+package gojson
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParseTreeNode is a node of the concrete derivation tree of a json text.
+// Nonterminals like <object fields> have the symbols they were derived into
+// as children, while terminals are the leaves holding the text they were lexed from
+type ParseTreeNode struct {
+	Symbol string
+	// Text is the part of the input the terminal was lexed from, empty for nonterminals
+	Text string
+	// Start and End are the byte offsets of the first byte and right after the last byte
+	// of the input the node spans
+	Start    int
+	End      int
+	Children []*ParseTreeNode
+}
+
+// IsTerminal checks if the node is a leaf of the tree
+func (n *ParseTreeNode) IsTerminal() bool {
+	return len(n.Children) == 0
+}
+
+// ParseTree parses the input like Parse, but returns the derivation tree
+// the grammar builds for it instead of the JsonValue
+func ParseTree(input string) (*ParseTreeNode, *Error) {
+	return ParseTreeWithOptions(input, ParseOptions{})
+}
+
+// ParseTreeWithOptions works like ParseTree, but lets the caller
+// configure how the input is interpreted
+func ParseTreeWithOptions(input string, opts ParseOptions) (*ParseTreeNode, *Error) {
+	tokens, err := lex(input, opts)
+	if err != nil {
+		return nil, err.locate(input)
+	}
+
+	r := newRecognizer()
+	r.opts = &opts
+	r.tracer = opts.Tracer
+	r.keepTree = true
+	if err := r.run(tokens, input); err != nil {
+		return nil, err.locate(input)
+	}
+	return r.nodes[0], nil
+}
+
+func (r *recognizer) shiftNode(terminal token) {
+	r.nodes = append(r.nodes, &ParseTreeNode{
+		Symbol: terminal.tokenType,
+		Text:   r.input[terminal.start:terminal.end],
+		Start:  terminal.start,
+		End:    terminal.end,
+	})
+}
+
+// reduceNodes replaces the nodes on top of the stack with their parent
+func (r *recognizer) reduceNodes(lhs elementType, size int) {
+	children := append([]*ParseTreeNode(nil), r.nodes[len(r.nodes)-size:]...)
+	r.nodes = append(r.nodes[:len(r.nodes)-size], &ParseTreeNode{
+		Symbol:   lhs,
+		Start:    children[0].Start,
+		End:      children[size-1].End,
+		Children: children,
+	})
+}
+
+// label is what the node is shown as: terminals by their text, nonterminals by their name
+func (n *ParseTreeNode) label() string {
+	if n.IsTerminal() && n.Text != "" {
+		return n.Text
+	}
+	return n.Symbol
+}
+
+// walk visits the nodes in preorder, passing the number of the node
+// and the number of its parent, which is -1 for the root
+func (n *ParseTreeNode) walk(visit func(node *ParseTreeNode, id int, parent int)) {
+	id := 0
+	var walk func(node *ParseTreeNode, parent int)
+	walk = func(node *ParseTreeNode, parent int) {
+		current := id
+		id++
+		visit(node, current, parent)
+		for _, child := range node.Children {
+			walk(child, current)
+		}
+	}
+	walk(n, -1)
+}
+
+var dotEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// DOT renders the tree in the Graphviz DOT language.
+// Terminals are drawn as boxes, nonterminals as ellipses
+func (n *ParseTreeNode) DOT() string {
+	var sb strings.Builder
+	sb.WriteString("digraph parse_tree {\n")
+	n.walk(func(node *ParseTreeNode, id int, parent int) {
+		shape := "ellipse"
+		if node.IsTerminal() {
+			shape = "box"
+		}
+		sb.WriteString(fmt.Sprintf("\tn%d [label=\"%s\", shape=%s];\n", id, dotEscaper.Replace(node.label()), shape))
+		if parent != -1 {
+			sb.WriteString(fmt.Sprintf("\tn%d -> n%d;\n", parent, id))
+		}
+	})
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+// mermaidEscaper replaces the characters mermaid would interpret with its entity codes
+var mermaidEscaper = strings.NewReplacer("#", "#35;", `"`, "#quot;", "<", "#lt;", ">", "#gt;", "\n", " ")
+
+// Mermaid renders the tree as a mermaid flowchart.
+// Terminals are drawn as rounded boxes, nonterminals as boxes
+func (n *ParseTreeNode) Mermaid() string {
+	var sb strings.Builder
+	sb.WriteString("flowchart TD\n")
+	n.walk(func(node *ParseTreeNode, id int, parent int) {
+		format := "\tn%d[\"%s\"]\n"
+		if node.IsTerminal() {
+			format = "\tn%d(\"%s\")\n"
+		}
+		sb.WriteString(fmt.Sprintf(format, id, mermaidEscaper.Replace(node.label())))
+		if parent != -1 {
+			sb.WriteString(fmt.Sprintf("\tn%d --> n%d\n", parent, id))
+		}
+	})
+	return sb.String()
+}
@@ -0,0 +1,85 @@
+package gojson
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseTree(t *testing.T) {
+	t.Run("the derivation is kept", func(t *testing.T) {
+		tree, err := ParseTree(`{"a": -1}`)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if tree.Symbol != value || tree.Start != 0 || tree.End != 9 {
+			t.Fatalf("unexpected root: %+v", tree)
+		}
+
+		objectNode := tree.Children[0]
+		if objectNode.Symbol != object || len(objectNode.Children) != 3 {
+			t.Fatalf("unexpected object: %+v", objectNode)
+		}
+
+		// { <object fields> }  ->  <object field>  ->  "a" : <value>
+		field := objectNode.Children[1].Children[0]
+		if field.Symbol != member || len(field.Children) != 3 {
+			t.Fatalf("unexpected object field: %+v", field)
+		}
+		key := field.Children[0]
+		if !key.IsTerminal() || key.Symbol != ltString || key.Text != `"a"` || key.Start != 1 || key.End != 4 {
+			t.Fatalf("unexpected key: %+v", key)
+		}
+
+		integerNode := field.Children[2].Children[0].Children[0]
+		if integerNode.Symbol != integer || integerNode.Start != 6 || integerNode.End != 8 {
+			t.Fatalf("unexpected integer: %+v", integerNode)
+		}
+	})
+
+	t.Run("errors are reported like Parse does", func(t *testing.T) {
+		_, err := ParseTree(`[1 2]`)
+		_, expected := Parse(`[1 2]`)
+		if err == nil || err.Error() != expected.Error() {
+			t.Fatalf("expected: %v, got: %v", expected, err)
+		}
+	})
+}
+
+func TestParseTreeExport(t *testing.T) {
+	tree, err := ParseTree(`["\""]`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	t.Run("dot", func(t *testing.T) {
+		dot := tree.DOT()
+		var expected = []string{
+			"digraph parse_tree {\n",
+			"\tn0 [label=\"<value>\", shape=ellipse];\n",
+			"\tn1 [label=\"<array>\", shape=ellipse];\n\tn0 -> n1;\n",
+			"\tn2 [label=\"[\", shape=box];\n\tn1 -> n2;\n",
+			`[label="\"\\\"\"", shape=box];`,
+		}
+		for _, part := range expected {
+			if !strings.Contains(dot, part) {
+				t.Fatalf("expected %q in:\n%s", part, dot)
+			}
+		}
+	})
+
+	t.Run("mermaid", func(t *testing.T) {
+		mermaid := tree.Mermaid()
+		var expected = []string{
+			"flowchart TD\n",
+			"\tn0[\"#lt;value#gt;\"]\n",
+			"\tn2(\"[\")\n\tn1 --> n2\n",
+			`("#quot;\#quot;#quot;")`,
+		}
+		for _, part := range expected {
+			if !strings.Contains(mermaid, part) {
+				t.Fatalf("expected %q in:\n%s", part, mermaid)
+			}
+		}
+	})
+}
@@ -3,7 +3,11 @@ package gojson
 import (
 	"errors"
 	"fmt"
+	"math"
+	"math/big"
 	"reflect"
+	"sort"
+	"strconv"
 )
 
 type JsonValueType = string
@@ -15,6 +19,9 @@ const (
 	NULL   JsonValueType = "NULL"
 	OBJECT JsonValueType = "OBJECT"
 	ARRAY  JsonValueType = "ARRAY"
+	// INVALID marks the place of malformed input in the result of ParseAll.
+	// The value of such a node is the *Error describing the problem
+	INVALID JsonValueType = "INVALID"
 )
 
 type JsonValue struct {
@@ -22,45 +29,27 @@ type JsonValue struct {
 	ValueType JsonValueType
 }
 
-type numberConverter = func(i float64) interface{}
-
-var numbers = map[reflect.Kind]numberConverter{
-	reflect.Int: func(i float64) interface{} {
-		return int(i)
-	},
-	reflect.Int8: func(i float64) interface{} {
-		return int8(i)
-	},
-	reflect.Int16: func(i float64) interface{} {
-		return int16(i)
-	},
-	reflect.Int32: func(i float64) interface{} {
-		return int32(i)
-	},
-	reflect.Int64: func(i float64) interface{} {
-		return int64(i)
-	},
-	reflect.Uint: func(i float64) interface{} {
-		return uint(i)
-	},
-	reflect.Uint8: func(i float64) interface{} {
-		return uint8(i)
-	},
-	reflect.Uint16: func(i float64) interface{} {
-		return uint16(i)
-	},
-	reflect.Uint32: func(i float64) interface{} {
-		return uint32(i)
-	},
-	reflect.Uint64: func(i float64) interface{} {
-		return uint64(i)
-	},
-	reflect.Float32: func(i float64) interface{} {
-		return float32(i)
-	},
-	reflect.Float64: func(i float64) interface{} {
-		return i
-	},
+type numberClass = uint8
+
+const (
+	signedNumber   numberClass = 0
+	unsignedNumber numberClass = 1
+	floatNumber    numberClass = 2
+)
+
+var numbers = map[reflect.Kind]numberClass{
+	reflect.Int:     signedNumber,
+	reflect.Int8:    signedNumber,
+	reflect.Int16:   signedNumber,
+	reflect.Int32:   signedNumber,
+	reflect.Int64:   signedNumber,
+	reflect.Uint:    unsignedNumber,
+	reflect.Uint8:   unsignedNumber,
+	reflect.Uint16:  unsignedNumber,
+	reflect.Uint32:  unsignedNumber,
+	reflect.Uint64:  unsignedNumber,
+	reflect.Float32: floatNumber,
+	reflect.Float64: floatNumber,
 }
 
 var supportedKinds = map[reflect.Kind]JsonValueType{
@@ -82,21 +71,97 @@ func isSupported(k reflect.Kind) (JsonValueType, bool) {
 	return "", false
 }
 
+var (
+	bigIntType   = reflect.TypeOf(big.Int{})
+	bigFloatType = reflect.TypeOf(big.Float{})
+	bigRatType   = reflect.TypeOf(big.Rat{})
+)
+
+// isBigNumber checks if t is one of the math/big number types or a pointer to one
+func isBigNumber(t reflect.Type) bool {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t == bigIntType || t == bigFloatType || t == bigRatType
+}
+
+func isSupportedType(t reflect.Type) (JsonValueType, bool) {
+	if isBigNumber(t) {
+		return NUMBER, true
+	}
+	return isSupported(t.Kind())
+}
+
+// UnknownFieldPolicy decides what happens to the members of objects
+// that have no matching field in the struct they are deserialized into
+type UnknownFieldPolicy = uint8
+
+const (
+	// UnknownFieldIgnore drops the unknown members
+	UnknownFieldIgnore UnknownFieldPolicy = 0
+	// UnknownFieldReject rejects the unknown members with an error
+	UnknownFieldReject UnknownFieldPolicy = 1
+	// UnknownFieldCollect stores the unknown members in the field of type map[string]JsonValue
+	// tagged with the extras option, e.g. `gojson:",extras"`. The unknown members of structs
+	// without such a field are dropped. Marshal writes the collected members back after the rest
+	UnknownFieldCollect UnknownFieldPolicy = 2
+)
+
+// UnmarshalOptions configures the behaviour of UnmarshalWithOptions.
+// The zero value results in the same behaviour as Unmarshal
+type UnmarshalOptions struct {
+	// Parse configures how the input json string is parsed
+	Parse ParseOptions
+	// AllowTruncation lets numbers with a fractional part be stored
+	// in integer kinds by dropping the fraction, e.g. 1.9 becomes 1.
+	// Numbers out of the range of the kind are rejected regardless
+	AllowTruncation bool
+	// UnknownFields is the policy for the members of objects without a matching field
+	UnknownFields UnknownFieldPolicy
+
+	// exactNumbers is set when the numbers have been kept as their literal regardless of Parse,
+	// so that integers and big numbers are converted without a detour through float64
+	exactNumbers bool
+}
+
+// withExactNumbers returns the options for parsing input that is only ever unmarshalled,
+// which keeps every number as its literal until the type it is stored in is known
+func (opts UnmarshalOptions) withExactNumbers() UnmarshalOptions {
+	opts.exactNumbers = !opts.Parse.UseNumber
+	opts.Parse.UseNumber = true
+	return opts
+}
+
 // Unmarshal deserializes the input json string into the provided object.
 // Please keep in mind that obj needs to be a pointer
-// to the object we want to deserialize the json into
+// to the object we want to deserialize the json into.
+// The members of objects are matched with the fields of structs by the names
+// the fields have in json, see Marshal for how their tags are interpreted
 func Unmarshal(inputJson string, ptr any) error {
-	json, err := Parse(inputJson)
+	return UnmarshalWithOptions(inputJson, ptr, UnmarshalOptions{})
+}
+
+// UnmarshalWithOptions works like Unmarshal, but lets the caller
+// configure how the input is parsed and deserialized
+func UnmarshalWithOptions(inputJson string, ptr any, opts UnmarshalOptions) error {
+	opts = opts.withExactNumbers()
+	json, err := ParseWithOptions(inputJson, opts.Parse)
 	if err != nil {
 		return err
 	}
-	return json.Unmarshal(ptr)
+	return json.UnmarshalWithOptions(ptr, opts)
 }
 
 // Unmarshal deserializes the parsed JsonValue into the provided object.
 // Please keep in mind that obj needs to be a pointer
 // to the object we want to deserialize the json into
 func (jv *JsonValue) Unmarshal(ptr any) error {
+	return jv.UnmarshalWithOptions(ptr, UnmarshalOptions{})
+}
+
+// UnmarshalWithOptions works like Unmarshal, but lets the caller
+// configure how the value is deserialized
+func (jv *JsonValue) UnmarshalWithOptions(ptr any, opts UnmarshalOptions) error {
 	v := reflect.ValueOf(ptr)
 
 	if v.Kind() != reflect.Pointer {
@@ -105,66 +170,346 @@ func (jv *JsonValue) Unmarshal(ptr any) error {
 
 	kind := v.Elem().Kind()
 
-	if _, ok := isSupported(kind); !ok {
+	if _, ok := isSupportedType(v.Elem().Type()); !ok {
 		return errors.New(fmt.Sprintf("unsupported type: %s", kind.String()))
 	}
 
-	return jv.setValue(kind, v.Elem())
+	return jv.setValue(kind, v.Elem(), "", &opts)
 }
 
-func (jv *JsonValue) setValue(kind reflect.Kind, v reflect.Value) error {
+func (jv *JsonValue) setValue(kind reflect.Kind, v reflect.Value, path string, opts *UnmarshalOptions) error {
+	if v.IsValid() && isBigNumber(v.Type()) {
+		return jv.setBigNumber(v, path)
+	}
+
 	jt, _ := isSupported(kind)
 	if jt != jv.ValueType {
-		return errors.New(fmt.Sprintf("type mismatch: expected: %s, provided: %s", jv.ValueType, jt))
+		return newUnmarshalError(path, fmt.Sprintf("type mismatch: expected: %s, provided: %s", jv.ValueType, jt))
 	}
 
 	if kind == reflect.String {
 		v.Set(reflect.ValueOf(jv.Value))
 	} else if kind == reflect.Bool {
 		v.Set(reflect.ValueOf(jv.Value))
-	} else if converter, ok := numbers[kind]; ok {
-		v.Set(reflect.ValueOf(converter(jv.Value.(float64))))
+	} else if class, ok := numbers[kind]; ok {
+		return jv.setNumber(class, v, path, opts)
 	} else if kind == reflect.Slice {
-		if err := jv.handleSlice(v, jt); err != nil {
+		if err := jv.handleSlice(v, path, opts); err != nil {
 			return err
 		}
 	} else if kind == reflect.Struct {
-		m := jv.Value.(map[string]JsonValue)
+		return jv.setStruct(v, path, opts)
+	}
+	return nil
+}
+
+func (jv *JsonValue) setStruct(v reflect.Value, path string, opts *UnmarshalOptions) error {
+	fields := map[string]structField{}
+	var extras reflect.Value
+	for _, field := range structFields(v.Type()) {
+		if !field.extras {
+			fields[field.name] = field
+		} else if !extras.IsValid() {
+			extras = v.FieldByIndex(field.index)
+		}
+	}
 
-		for k, val := range m {
-			f := v.FieldByName(k)
-			if err := val.setValue(f.Kind(), f); err != nil {
+	members := jv.Value.(map[string]JsonValue)
+	keys := make([]string, 0, len(members))
+	for k := range members {
+		keys = append(keys, k)
+	}
+	// the order of the keys decides which error is reported first
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		val := members[k]
+		field, ok := fields[k]
+		if !ok {
+			if err := val.setUnknown(k, extras, path, opts); err != nil {
 				return err
 			}
+			continue
 		}
 
+		f := v.FieldByIndex(field.index)
+		if field.asString && quotable(f.Type()) {
+			var err error
+			if val, err = val.unquote(fieldPath(path, k), opts); err != nil {
+				return err
+			}
+		}
+		if err := val.setValue(f.Kind(), f, fieldPath(path, k), opts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setUnknown deals with a member without a field according to the policy,
+// path being the path of the object the member is in
+func (jv *JsonValue) setUnknown(key string, extras reflect.Value, path string, opts *UnmarshalOptions) error {
+	switch opts.UnknownFields {
+	case UnknownFieldReject:
+		return newUnmarshalError(path, fmt.Sprintf("unknown field %q", key))
+	case UnknownFieldCollect:
+		if !extras.IsValid() {
+			return nil
+		}
+		member := *jv
+		if opts.exactNumbers {
+			var err error
+			if member, err = member.parsedNumbers(fieldPath(path, key), &opts.Parse); err != nil {
+				return err
+			}
+		}
+		if extras.IsNil() {
+			extras.Set(reflect.MakeMap(extrasType))
+		}
+		extras.SetMapIndex(reflect.ValueOf(key), reflect.ValueOf(member))
 	}
 	return nil
 }
 
-func (jv *JsonValue) handleSlice(v reflect.Value, jt JsonValueType) error {
+// parsedNumbers turns the exact numbers of the value into what parsing with the options
+// produces in the first place, for the values that are handed out as they are
+func (jv JsonValue) parsedNumbers(path string, opts *ParseOptions) (JsonValue, error) {
+	switch jv.ValueType {
+	case NUMBER:
+		literal, ok := jv.Value.(JsonNumber)
+		if !ok {
+			return jv, nil
+		}
+		f, err := literal.Float64()
+		if err != nil {
+			switch opts.NumberOverflow {
+			case OverflowClamp:
+				// ParseFloat has already clamped the value to ±Inf
+			case OverflowExact:
+				return jv, nil
+			default:
+				return JsonValue{}, newUnmarshalError(path, fmt.Sprintf("number out of range: %s", literal))
+			}
+		}
+		return JsonValue{Value: f, ValueType: NUMBER}, nil
+	case OBJECT:
+		members := map[string]JsonValue{}
+		for key, member := range jv.Value.(map[string]JsonValue) {
+			var err error
+			if members[key], err = member.parsedNumbers(fieldPath(path, key), opts); err != nil {
+				return JsonValue{}, err
+			}
+		}
+		return JsonValue{Value: members, ValueType: OBJECT}, nil
+	case ARRAY:
+		elements := jv.Value.([]JsonValue)
+		parsed := make([]JsonValue, len(elements))
+		for i, element := range elements {
+			var err error
+			if parsed[i], err = element.parsedNumbers(indexPath(path, i), opts); err != nil {
+				return JsonValue{}, err
+			}
+		}
+		return JsonValue{Value: parsed, ValueType: ARRAY}, nil
+	}
+	return jv, nil
+}
+
+// unquote parses the value of a field with the string tag option, a number or a boolean written as a json string
+func (jv *JsonValue) unquote(path string, opts *UnmarshalOptions) (JsonValue, error) {
+	if jv.ValueType != STRING {
+		return JsonValue{}, newUnmarshalError(path, fmt.Sprintf("expected a string holding the value, provided: %s", jv.ValueType))
+	}
+	literal := jv.Value.(string)
+	parseOpts := opts.Parse
+	// the literal is kept as it is, so that no digits are lost on the way to the field
+	parseOpts.UseNumber = true
+	json, err := ParseWithOptions(literal, parseOpts)
+	if err != nil || (json.ValueType != NUMBER && json.ValueType != BOOL) {
+		return JsonValue{}, newUnmarshalError(path, fmt.Sprintf("invalid number or boolean in string: %q", literal))
+	}
+	return json, nil
+}
+
+func (jv *JsonValue) handleSlice(v reflect.Value, path string, opts *UnmarshalOptions) error {
 	dataType := v.Type().Elem().Kind()
 
 	values := jv.Value.([]JsonValue)
-	var jsonType = values[0].ValueType
 
 	for _, value := range values {
-		if value.ValueType != jsonType {
-			return errors.New("json array does not have elements of one type")
+		if value.ValueType != values[0].ValueType {
+			return newUnmarshalError(path, "json array does not have elements of one type")
 		}
 	}
 
-	if jt, ok := isSupported(dataType); !ok || jt != jsonType {
-		return errors.New("type mismatch for array")
+	if jt, ok := isSupportedType(v.Type().Elem()); !ok || (len(values) > 0 && jt != values[0].ValueType) {
+		return newUnmarshalError(path, "type mismatch for array")
 	}
 
 	refSlice := reflect.MakeSlice(reflect.SliceOf(v.Type().Elem()), len(values), len(values))
 
 	for i := 0; i < len(values); i++ {
-		if err := values[i].setValue(dataType, refSlice.Index(i)); err != nil {
+		if err := values[i].setValue(dataType, refSlice.Index(i), indexPath(path, i), opts); err != nil {
 			return err
 		}
 	}
 	v.Set(refSlice)
 	return nil
 }
+
+func fieldPath(path, field string) string {
+	if path == "" {
+		return field
+	}
+	return path + "." + field
+}
+
+func indexPath(path string, i int) string {
+	return fmt.Sprintf("%s[%d]", path, i)
+}
+
+// setNumber stores the number in a numeric kind, making sure
+// that the value neither overflows the kind nor loses its fractional part
+func (jv *JsonValue) setNumber(class numberClass, v reflect.Value, path string, opts *UnmarshalOptions) error {
+	f, isFloat := jv.Value.(float64)
+
+	if class == floatNumber {
+		if !isFloat {
+			var err error
+			f, err = jv.Value.(JsonNumber).Float64()
+			// ParseFloat clamps the values out of range to ±Inf, which parsing would have kept
+			clamp := opts.exactNumbers && opts.Parse.NumberOverflow == OverflowClamp
+			if err != nil && !clamp {
+				return jv.overflowError(v, path)
+			}
+		}
+		if v.OverflowFloat(f) {
+			return jv.overflowError(v, path)
+		}
+		v.SetFloat(f)
+		return nil
+	}
+
+	if isFloat {
+		return jv.setInteger(f, class, v, path, opts)
+	}
+
+	// plain integer literals, by far the most common ones, do not need big numbers either
+	literal := jv.Value.(JsonNumber)
+	if class == signedNumber {
+		if i, err := strconv.ParseInt(string(literal), 10, 64); err == nil {
+			if v.OverflowInt(i) {
+				return jv.overflowError(v, path)
+			}
+			v.SetInt(i)
+			return nil
+		}
+	} else if u, err := strconv.ParseUint(string(literal), 10, 64); err == nil {
+		if v.OverflowUint(u) {
+			return jv.overflowError(v, path)
+		}
+		v.SetUint(u)
+		return nil
+	}
+
+	r, err := literal.Rat()
+	if err != nil {
+		// ±Inf and NaN have no rational representation
+		return jv.overflowError(v, path)
+	}
+
+	if !r.IsInt() && !opts.AllowTruncation {
+		return jv.fractionError(v, path)
+	}
+
+	integer := new(big.Int).Quo(r.Num(), r.Denom()) // truncates towards zero
+
+	if class == signedNumber {
+		if !integer.IsInt64() || v.OverflowInt(integer.Int64()) {
+			return jv.overflowError(v, path)
+		}
+		v.SetInt(integer.Int64())
+	} else {
+		if !integer.IsUint64() || v.OverflowUint(integer.Uint64()) {
+			return jv.overflowError(v, path)
+		}
+		v.SetUint(integer.Uint64())
+	}
+	return nil
+}
+
+// setInteger stores a float64 in an integer kind without going through big numbers.
+// Every float64 of 2^53 or more is an integer, so only the range needs checking for them
+func (jv *JsonValue) setInteger(f float64, class numberClass, v reflect.Value, path string, opts *UnmarshalOptions) error {
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return jv.overflowError(v, path)
+	}
+
+	integer := math.Trunc(f)
+	if integer != f && !opts.AllowTruncation {
+		return jv.fractionError(v, path)
+	}
+
+	// 2^63 and 2^64 are exact as float64, unlike the largest int64 and uint64
+	if class == signedNumber {
+		if integer < -(1<<63) || integer >= 1<<63 || v.OverflowInt(int64(integer)) {
+			return jv.overflowError(v, path)
+		}
+		v.SetInt(int64(integer))
+	} else {
+		if integer < 0 || integer >= 1<<64 || v.OverflowUint(uint64(integer)) {
+			return jv.overflowError(v, path)
+		}
+		v.SetUint(uint64(integer))
+	}
+	return nil
+}
+
+// overflowError and fractionError quote the number as it is written in the input.
+// Numbers parsed into a float64 by the caller have no literal left, their shortest form is quoted instead
+func (jv *JsonValue) overflowError(v reflect.Value, path string) error {
+	literal, _ := asJsonNumber(jv.Value)
+	return newUnmarshalError(path, fmt.Sprintf("number %s overflows %s", literal, v.Type()))
+}
+
+func (jv *JsonValue) fractionError(v reflect.Value, path string) error {
+	literal, _ := asJsonNumber(jv.Value)
+	return newUnmarshalError(path, fmt.Sprintf("number %s is not an integer and cannot be stored in %s", literal, v.Type()))
+}
+
+// setBigNumber sets big.Int, big.Float and big.Rat values
+// as well as pointers to them from the exact literal of a number
+func (jv *JsonValue) setBigNumber(v reflect.Value, path string) error {
+	if jv.ValueType != NUMBER {
+		return newUnmarshalError(path, fmt.Sprintf("type mismatch: expected: %s, provided: %s", jv.ValueType, NUMBER))
+	}
+
+	n, _ := asJsonNumber(jv.Value)
+
+	t := v.Type()
+	isPointer := t.Kind() == reflect.Pointer
+	if isPointer {
+		t = t.Elem()
+	}
+
+	var result interface{}
+	var err error
+	switch t {
+	case bigIntType:
+		result, err = n.BigInt()
+	case bigFloatType:
+		result, err = n.BigFloat()
+	case bigRatType:
+		result, err = n.Rat()
+	}
+	if err != nil {
+		return newUnmarshalError(path, err.Error())
+	}
+
+	if isPointer {
+		v.Set(reflect.ValueOf(result))
+	} else {
+		v.Set(reflect.ValueOf(result).Elem())
+	}
+	return nil
+}
@@ -2,7 +2,10 @@ package gojson
 
 import (
 	"fmt"
+	"math"
+	"math/big"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -56,6 +59,10 @@ func TestUnmarshall(t *testing.T) {
 	var nums []float64
 	doTest(&nums, `[1, 2, 3]`, []float64{1, 2, 3})
 
+	// empty slice
+	var empty []float64
+	doTest(&empty, `[]`, []float64{})
+
 	// object
 	var person Person
 	doTest(&person, `{"Name": "John", "Age": 25}`, Person{Name: "John", Age: 25})
@@ -75,3 +82,290 @@ func TestUnmarshall(t *testing.T) {
 		LuckyNumbers: []int{-1, 0, 1, 1022},
 	})
 }
+
+func TestUnmarshalNumberRanges(t *testing.T) {
+	var testCases = []struct {
+		name     string
+		input    string
+		ptr      interface{}
+		errorMsg string
+	}{
+		{"uint8 overflow", `300`, new(uint8), "number 300 overflows uint8"},
+		{"negative uint", `-1`, new(uint), "number -1 overflows uint"},
+		{"int8 underflow", `-129`, new(int8), "number -129 overflows int8"},
+		{"int64 overflow", `1e19`, new(int64), "number 1e19 overflows int64"},
+		{"uint64 overflow", `1e20`, new(uint64), "number 1e20 overflows uint64"},
+		{"float32 overflow", `1e39`, new(float32), "number 1e39 overflows float32"},
+		{"fraction into int", `1.5`, new(int), "number 1.5 is not an integer and cannot be stored in int"},
+		{"struct field path", `{"Name": "John", "Age": 256}`, new(Person), "Age: number 256 overflows uint8"},
+		{"nested field path", `{"Person": {"Age": -3}}`, new(ComplexPerson), "Person.Age: number -3 overflows uint8"},
+		{"slice index path", `{"LuckyNumbers": [1, 2, 3.25]}`, new(ComplexPerson), "LuckyNumbers[2]: number 3.25 is not an integer and cannot be stored in int"},
+		{"literal as written", `1E19`, new(int64), "number 1E19 overflows int64"},
+		{"trailing zeros as written", `2.50`, new(int), "number 2.50 is not an integer and cannot be stored in int"},
+		{"largest int64 plus one", `9223372036854775808`, new(int64), "number 9223372036854775808 overflows int64"},
+		{"literal in string", `{"id": "1e20"}`, new(account), "id: number 1e20 overflows uint64"},
+	}
+
+	for _, data := range testCases {
+		t.Run(data.name, func(t *testing.T) {
+			err := Unmarshal(data.input, data.ptr)
+			if err == nil {
+				t.Fatalf("error value was required")
+			}
+			if _, ok := err.(*UnmarshalError); !ok {
+				t.Fatalf("expected an *UnmarshalError, got: %T", err)
+			}
+			if err.Error() != data.errorMsg {
+				t.Fatalf("expected: %s, got: %s", data.errorMsg, err.Error())
+			}
+		})
+	}
+
+	t.Run("limits of each kind", func(t *testing.T) {
+		var i8 int8
+		var u16 uint16
+		var i64 int64
+		if err := Unmarshal(`-128`, &i8); err != nil || i8 != -128 {
+			t.Errorf("unexpected: %d, %v", i8, err)
+		}
+		if err := Unmarshal(`65535`, &u16); err != nil || u16 != 65535 {
+			t.Errorf("unexpected: %d, %v", u16, err)
+		}
+		if err := UnmarshalWithOptions(`9223372036854775807`, &i64, UnmarshalOptions{Parse: ParseOptions{UseNumber: true}}); err != nil || i64 != 9223372036854775807 {
+			t.Errorf("unexpected: %d, %v", i64, err)
+		}
+		if err := Unmarshal(`2.5e2`, &u16); err != nil || u16 != 250 {
+			t.Errorf("unexpected: %d, %v", u16, err)
+		}
+		if err := Unmarshal(`-9223372036854775808`, &i64); err != nil || i64 != -9223372036854775808 {
+			t.Errorf("unexpected: %d, %v", i64, err)
+		}
+		if err := Unmarshal(`9223372036854775807`, &i64); err != nil || i64 != 9223372036854775807 {
+			t.Errorf("unexpected: %d, %v", i64, err)
+		}
+	})
+
+	t.Run("integers are converted from the literal", func(t *testing.T) {
+		var i64 int64
+		if err := Unmarshal(`9007199254740993`, &i64); err != nil || i64 != 9007199254740993 {
+			t.Errorf("unexpected: %d, %v", i64, err)
+		}
+		if err := NewDecoder(strings.NewReader(`9007199254740993`)).Decode(&i64); err != nil || i64 != 9007199254740993 {
+			t.Errorf("unexpected: %d, %v", i64, err)
+		}
+		var u64 uint64
+		if err := Unmarshal(`18446744073709551615`, &u64); err != nil || u64 != 18446744073709551615 {
+			t.Errorf("unexpected: %d, %v", u64, err)
+		}
+
+		var n struct{ N *big.Int }
+		if err := Unmarshal(`{"N": 123456789012345678901234567890}`, &n); err != nil || n.N.String() != "123456789012345678901234567890" {
+			t.Errorf("unexpected: %v, %v", n.N, err)
+		}
+	})
+
+	t.Run("the parse options still apply to floats", func(t *testing.T) {
+		var f float64
+		opts := UnmarshalOptions{Parse: ParseOptions{NumberOverflow: OverflowClamp}}
+		if err := UnmarshalWithOptions(`-1e999`, &f, opts); err != nil || !math.IsInf(f, -1) {
+			t.Errorf("unexpected: %v, %v", f, err)
+		}
+		if err := Unmarshal(`1e999`, &f); err == nil || err.Error() != "number 1e999 overflows float64" {
+			t.Errorf("unexpected: %v, %v", f, err)
+		}
+	})
+
+	t.Run("decoded literal as written", func(t *testing.T) {
+		var people []Person
+		err := NewDecoder(strings.NewReader(`[{"Age": 2.56e2}]`)).Decode(&people)
+		if err == nil || err.Error() != "[0].Age: number 2.56e2 overflows uint8" {
+			t.Errorf("unexpected: %v", err)
+		}
+	})
+
+	t.Run("float64 values are described by their shortest form", func(t *testing.T) {
+		json := JsonValue{float64(1e19), NUMBER}
+		var i int64
+		if err := json.Unmarshal(&i); err == nil || err.Error() != "number 1e+19 overflows int64" {
+			t.Errorf("unexpected: %v", err)
+		}
+	})
+
+	t.Run("float64 values do not allocate", func(t *testing.T) {
+		json := JsonValue{float64(42), NUMBER}
+		var i int
+		allocs := testing.AllocsPerRun(100, func() {
+			if err := json.Unmarshal(&i); err != nil || i != 42 {
+				t.Fatalf("unexpected: %d, %v", i, err)
+			}
+		})
+		if allocs != 0 {
+			t.Errorf("expected no allocations, got: %v", allocs)
+		}
+	})
+
+	t.Run("truncation can be allowed", func(t *testing.T) {
+		opts := UnmarshalOptions{AllowTruncation: true}
+		var i int
+		if err := UnmarshalWithOptions(`-1.9`, &i, opts); err != nil || i != -1 {
+			t.Errorf("unexpected: %d, %v", i, err)
+		}
+		var u uint8
+		if err := UnmarshalWithOptions(`255.99`, &u, opts); err != nil || u != 255 {
+			t.Errorf("unexpected: %d, %v", u, err)
+		}
+		if err := UnmarshalWithOptions(`256.5`, &u, opts); err == nil {
+			t.Errorf("overflow must be rejected even if truncation is allowed")
+		}
+	})
+}
+
+type timestamps struct {
+	Created int64 `json:"created_at"`
+	Updated int64 `json:"updated_at,omitempty"`
+}
+
+type account struct {
+	timestamps
+	ID       uint64   `json:"id,string"`
+	Name     string   `json:"name" gojson:"display_name"`
+	Active   bool     `json:",string"`
+	Password string   `json:"-"`
+	Dash     string   `json:"-,"`
+	Balance  *big.Int `gojson:"balance,string"`
+	internal string
+}
+
+func TestUnmarshalStructTags(t *testing.T) {
+	input := `{"created_at": 1700000000, "id": "18446744073709551615", "display_name": "Jane",
+		"Active": "true", "-": "dash", "balance": "123456789012345678901234567890"}`
+
+	var a account
+	if err := Unmarshal(input, &a); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	balance, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	expected := account{
+		timestamps: timestamps{Created: 1700000000},
+		ID:         18446744073709551615,
+		Name:       "Jane",
+		Active:     true,
+		Dash:       "dash",
+		Balance:    balance,
+	}
+	if !reflect.DeepEqual(a, expected) {
+		t.Fatalf("expected: %+v, got: %+v", expected, a)
+	}
+
+	var testCases = []struct {
+		name     string
+		input    string
+		errorMsg string
+	}{
+		{"number instead of string", `{"id": 12}`, "id: expected a string holding the value, provided: NUMBER"},
+		{"text in string", `{"id": "twelve"}`, `id: invalid number or boolean in string: "twelve"`},
+		{"object in string", `{"Active": "{}"}`, `Active: invalid number or boolean in string: "{}"`},
+		{"skipped field", `{"Password": "secret"}`, `unknown field "Password"`},
+		{"go name of tagged field", `{"Name": "Jane"}`, `unknown field "Name"`},
+	}
+	for _, data := range testCases {
+		t.Run(data.name, func(t *testing.T) {
+			err := UnmarshalWithOptions(data.input, new(account), UnmarshalOptions{UnknownFields: UnknownFieldReject})
+			if err == nil || err.Error() != data.errorMsg {
+				t.Fatalf("expected: %s, got: %v", data.errorMsg, err)
+			}
+		})
+	}
+}
+
+func TestStructFields(t *testing.T) {
+	type inner struct {
+		Shadowed string
+		Promoted string
+	}
+	type Named struct {
+		Value int
+	}
+	type outer struct {
+		inner
+		Named    `json:"named"`
+		Shadowed string `json:"Shadowed"`
+		First    int    `json:"same"`
+		Second   int    `gojson:"same"`
+	}
+
+	var names []string
+	for _, field := range structFields(reflect.TypeOf(outer{})) {
+		names = append(names, fmt.Sprintf("%s%v", field.name, field.index))
+	}
+	expected := []string{"Promoted[0 1]", "named[1]", "Shadowed[2]", "same[3]"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Fatalf("expected: %v, got: %v", expected, names)
+	}
+}
+
+type document struct {
+	Title  string
+	Author Person
+	Extras map[string]JsonValue `gojson:",extras"`
+}
+
+func TestUnmarshalUnknownFields(t *testing.T) {
+	input := `{"Title": "notes", "Author": {"Name": "Jane", "Age": 30, "Email": "jane@example.com"}, "z": [1], "a": null}`
+
+	t.Run("ignore", func(t *testing.T) {
+		var d document
+		if err := Unmarshal(input, &d); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		expected := document{Title: "notes", Author: Person{"Jane", 30}}
+		if !reflect.DeepEqual(d, expected) {
+			t.Fatalf("expected: %+v, got: %+v", expected, d)
+		}
+	})
+
+	t.Run("reject", func(t *testing.T) {
+		opts := UnmarshalOptions{UnknownFields: UnknownFieldReject}
+		err := UnmarshalWithOptions(`{"Title": "notes", "z": 1}`, new(document), opts)
+		if err == nil || err.Error() != `unknown field "z"` {
+			t.Fatalf("expected an error for the unknown field, got: %v", err)
+		}
+
+		err = UnmarshalWithOptions(input, new(document), opts)
+		if err == nil || err.Error() != `Author: unknown field "Email"` {
+			t.Fatalf("expected an error for the unknown field, got: %v", err)
+		}
+		if _, ok := err.(*UnmarshalError); !ok {
+			t.Fatalf("expected an *UnmarshalError, got: %T", err)
+		}
+	})
+
+	t.Run("collect", func(t *testing.T) {
+		var d document
+		if err := UnmarshalWithOptions(input, &d, UnmarshalOptions{UnknownFields: UnknownFieldCollect}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		expected := document{
+			Title:  "notes",
+			Author: Person{"Jane", 30},
+			Extras: map[string]JsonValue{
+				"z": {[]JsonValue{{float64(1), NUMBER}}, ARRAY},
+				"a": {nil, NULL},
+			},
+		}
+		if !reflect.DeepEqual(d, expected) {
+			t.Fatalf("expected: %+v, got: %+v", expected, d)
+		}
+
+		// the collected members are written back
+		data, err := Marshal(d)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		serialized := `{"Title":"notes","Author":{"Name":"Jane","Age":30},"a":null,"z":[1]}`
+		if string(data) != serialized {
+			t.Fatalf("expected: %s, got: %s", serialized, data)
+		}
+	})
+}